@@ -44,6 +44,7 @@ var (
 type config struct {
 	devices            string
 	metadataDevice     string
+	walDevice          string
 	dataDir            string
 	forceFormat        bool
 	location           string
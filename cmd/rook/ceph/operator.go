@@ -17,10 +17,13 @@ limitations under the License.
 package ceph
 
 import (
+	netclient "github.com/k8snetworkplumbingwg/network-attachment-definition-client/pkg/client/clientset/versioned/typed/k8s.cni.cncf.io/v1"
 	"github.com/pkg/errors"
 	"github.com/rook/rook/cmd/rook/rook"
+	rookclient "github.com/rook/rook/pkg/client/clientset/versioned"
 	"github.com/rook/rook/pkg/clusterd"
 	"github.com/rook/rook/pkg/daemon/ceph/agent/flexvolume/attachment"
+	cephclient "github.com/rook/rook/pkg/daemon/ceph/client"
 	operator "github.com/rook/rook/pkg/operator/ceph"
 	cluster "github.com/rook/rook/pkg/operator/ceph/cluster"
 	"github.com/rook/rook/pkg/operator/ceph/cluster/mon"
@@ -28,8 +31,11 @@ import (
 
 	opcontroller "github.com/rook/rook/pkg/operator/ceph/controller"
 	"github.com/rook/rook/pkg/operator/k8sutil"
+	"github.com/rook/rook/pkg/util/exec"
 	"github.com/rook/rook/pkg/util/flags"
 	"github.com/spf13/cobra"
+	apiextensionsclient "k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset"
+	"k8s.io/client-go/kubernetes"
 )
 
 const (
@@ -50,6 +56,12 @@ func init() {
 	operatorCmd.Flags().BoolVar(&operator.EnableFlexDriver, "enable-flex-driver", true, "enable the rook flex driver")
 	operatorCmd.Flags().BoolVar(&operator.EnableDiscoveryDaemon, "enable-discovery-daemon", true, "enable the rook discovery daemon")
 
+	// leader election to run multiple operator replicas in active/standby mode
+	operatorCmd.Flags().BoolVar(&operator.EnableLeaderElection, "enable-leader-election", false, "run the operator only when holding the leader lease so multiple replicas can be deployed")
+	operatorCmd.Flags().DurationVar(&operator.LeaderElectionLeaseDuration, "leader-election-lease-duration", operator.LeaderElectionLeaseDuration, "duration standby operators wait before taking over the leader lease (duration)")
+	operatorCmd.Flags().DurationVar(&operator.LeaderElectionRenewDeadline, "leader-election-renew-deadline", operator.LeaderElectionRenewDeadline, "duration the leader retries renewing the leader lease before giving up (duration)")
+	operatorCmd.Flags().DurationVar(&operator.LeaderElectionRetryPeriod, "leader-election-retry-period", operator.LeaderElectionRetryPeriod, "duration between attempts to acquire or renew the leader lease (duration)")
+
 	// csi deployment templates
 	operatorCmd.Flags().StringVar(&csi.RBDPluginTemplatePath, "csi-rbd-plugin-template-path", csi.DefaultRBDPluginTemplatePath, "path to ceph-csi rbd plugin template")
 
@@ -58,6 +70,8 @@ func init() {
 	operatorCmd.Flags().StringVar(&csi.CephFSPluginTemplatePath, "csi-cephfs-plugin-template-path", csi.DefaultCephFSPluginTemplatePath, "path to ceph-csi cephfs plugin template")
 	operatorCmd.Flags().StringVar(&csi.CephFSProvisionerDepTemplatePath, "csi-cephfs-provisioner-dep-template-path", csi.DefaultCephFSProvisionerDepTemplatePath, "path to ceph-csi cephfs provisioner deployment template")
 
+	operatorCmd.Flags().BoolVar(&opcontroller.DryRun, "dry-run", false, "only report the changes the operator would apply without applying them")
+
 	operatorCmd.Flags().BoolVar(&cluster.EnableMachineDisruptionBudget, "enable-machine-disruption-budget", false, "enable fencing controllers")
 
 	flags.SetFlagsFromEnv(operatorCmd.Flags(), rook.RookEnvVarPrefix)
@@ -75,6 +89,9 @@ func startOperator(cmd *cobra.Command, args []string) error {
 	context := createContext()
 	context.NetworkInfo = clusterd.NetworkInfo{}
 	context.ConfigDir = k8sutil.DataDir
+	if opcontroller.DryRun {
+		enableDryRun(context)
+	}
 	volumeAttachment, err := attachment.New(context)
 	if err != nil {
 		rook.TerminateFatal(err)
@@ -90,10 +107,38 @@ func startOperator(cmd *cobra.Command, args []string) error {
 
 	serviceAccountName := rook.GetOperatorServiceAccount(context.Clientset)
 	op := operator.New(context, volumeAttachment, rookImage, serviceAccountName)
-	err = op.Run()
+	err = op.RunWithLeaderElection()
 	if err != nil {
 		rook.TerminateFatal(errors.Wrap(err, "failed to run operator\n"))
 	}
 
 	return nil
 }
+
+// enableDryRun changes the context so that the changes to the Kubernetes resources are only sent to
+// the API server in dry-run mode and the ceph commands which would change the cluster are skipped
+func enableDryRun(context *clusterd.Context) {
+	logger.Warning("running the operator in dry-run mode, the planned changes are reported but not applied")
+	var err error
+	context.KubeConfig.Wrap(k8sutil.WrapDryRunTransport)
+
+	context.Clientset, err = kubernetes.NewForConfig(context.KubeConfig)
+	rook.TerminateOnError(err, "failed to create dry-run k8s clientset")
+
+	context.APIExtensionClientset, err = apiextensionsclient.NewForConfig(context.KubeConfig)
+	rook.TerminateOnError(err, "failed to create dry-run k8s API extension clientset")
+
+	context.RookClientset, err = rookclient.NewForConfig(context.KubeConfig)
+	rook.TerminateOnError(err, "failed to create dry-run rook clientset")
+
+	context.NetworkClient, err = netclient.NewForConfig(context.KubeConfig)
+	rook.TerminateOnError(err, "failed to create dry-run network clientset")
+
+	context.Executor = &exec.DryRunCommandExecutor{
+		Executor: context.Executor,
+		Allowed:  cephclient.IsReadOnlyCommand,
+		Skipped: func(command string, arg ...string) {
+			k8sutil.DryRunChanges.Record(cephclient.CommandClusterNamespace(arg...), "run "+cephclient.CommandString(command, arg...))
+		},
+	}
+}
@@ -50,6 +50,10 @@ var osdStartCmd = &cobra.Command{
 	Use:   "start",
 	Short: "Starts the osd daemon", // OSDs that were provisioned by ceph-volume
 }
+var rotateKeyCmd = &cobra.Command{
+	Use:   "rotate-key",
+	Short: "Rotates the encryption key of the osd blocks",
+}
 
 var (
 	osdDataDeviceFilter     string
@@ -67,6 +71,8 @@ var (
 	blockPath               string
 	lvBackedPV              bool
 	driveGroups             string
+	encryptionSecretName    string
+	encryptedBlocks         []string
 )
 
 func addOSDFlags(command *cobra.Command) {
@@ -79,6 +85,7 @@ func addOSDFlags(command *cobra.Command) {
 	provisionCmd.Flags().StringVar(&osdDataDeviceFilter, "data-device-filter", "", "a regex filter for the device names to use, or \"all\"")
 	provisionCmd.Flags().StringVar(&osdDataDevicePathFilter, "data-device-path-filter", "", "a regex filter for the device path names to use")
 	provisionCmd.Flags().StringVar(&cfg.metadataDevice, "metadata-device", "", "device to use for metadata (e.g. a high performance SSD/NVMe device)")
+	provisionCmd.Flags().StringVar(&cfg.walDevice, "wal-device", "", "device to use for the write ahead log (e.g. a high performance NVMe device), requires a metadata device")
 	provisionCmd.Flags().BoolVar(&cfg.forceFormat, "force-format", false,
 		"true to force the format of any specified devices, even if they already have a filesystem.  BE CAREFUL!")
 	provisionCmd.Flags().BoolVar(&cfg.pvcBacked, "pvc-backed-osd", false, "true to specify a block mode pvc is backing the OSD")
@@ -94,10 +101,15 @@ func addOSDFlags(command *cobra.Command) {
 	osdStartCmd.Flags().StringVar(&blockPath, "block-path", "", "Block path for the OSD created by ceph-volume")
 	osdStartCmd.Flags().BoolVar(&lvBackedPV, "lv-backed-pv", false, "Whether the PV located on LV")
 
+	// flags for rotating the encryption key of the osds on pvc
+	rotateKeyCmd.Flags().StringVar(&encryptionSecretName, "secret-name", "", "the secret with the encryption key of the osd")
+	rotateKeyCmd.Flags().StringSliceVar(&encryptedBlocks, "encrypted-blocks", nil, "comma separated list of the encrypted blocks of the osd")
+
 	// add the subcommands to the parent osd command
 	osdCmd.AddCommand(osdConfigCmd,
 		provisionCmd,
-		osdStartCmd)
+		osdStartCmd,
+		rotateKeyCmd)
 }
 
 func addOSDConfigFlags(command *cobra.Command) {
@@ -111,6 +123,8 @@ func addOSDConfigFlags(command *cobra.Command) {
 	command.Flags().StringVar(&cfg.storeConfig.StoreType, "osd-store", "", "type of backing OSD store to use (bluestore or filestore)")
 	command.Flags().IntVar(&cfg.storeConfig.OSDsPerDevice, "osds-per-device", 1, "the number of OSDs per device")
 	command.Flags().BoolVar(&cfg.storeConfig.EncryptedDevice, "encrypted-device", false, "whether to encrypt the OSD with dmcrypt")
+	command.Flags().BoolVar(&cfg.storeConfig.SkipUnhealthyDevices, "skip-unhealthy-devices", false, "whether to skip the devices whose SMART health self-assessment failed")
+	command.Flags().IntVar(&cfg.storeConfig.MaxMediaWearout, "max-media-wearout", 0, "skip the devices whose media wearout percentage reached this value (0 for no limit)")
 }
 
 func init() {
@@ -120,10 +134,12 @@ func init() {
 	flags.SetFlagsFromEnv(osdConfigCmd.Flags(), rook.RookEnvVarPrefix)
 	flags.SetFlagsFromEnv(provisionCmd.Flags(), rook.RookEnvVarPrefix)
 	flags.SetFlagsFromEnv(osdStartCmd.Flags(), rook.RookEnvVarPrefix)
+	flags.SetFlagsFromEnv(rotateKeyCmd.Flags(), rook.RookEnvVarPrefix)
 
 	osdConfigCmd.RunE = writeOSDConfig
 	provisionCmd.RunE = prepareOSD
 	osdStartCmd.RunE = startOSD
+	rotateKeyCmd.RunE = rotateKey
 }
 
 // Start the osd daemon if provisioned by ceph-volume
@@ -145,6 +161,27 @@ func startOSD(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+// Rotate the encryption key of the blocks of an osd on pvc
+func rotateKey(cmd *cobra.Command, args []string) error {
+	required := []string{"secret-name"}
+	if err := flags.VerifyRequiredFlags(rotateKeyCmd, required); err != nil {
+		return err
+	}
+	if len(encryptedBlocks) == 0 {
+		return errors.New("no encrypted block specified")
+	}
+
+	rook.SetLogLevel()
+	rook.LogStartupInfo(rotateKeyCmd.Flags())
+
+	context := createContext()
+	err := osddaemon.RotateEncryptionKey(context, clusterInfo.Namespace, encryptionSecretName, oposd.OsdEncryptionSecretNameKeyName, encryptedBlocks)
+	if err != nil {
+		rook.TerminateFatal(err)
+	}
+	return nil
+}
+
 func verifyConfigFlags(configCmd *cobra.Command) error {
 	required := []string{"cluster-id", "node-name"}
 	if err := flags.VerifyRequiredFlags(configCmd, required); err != nil {
@@ -221,7 +258,7 @@ func prepareOSD(cmd *cobra.Command, args []string) error {
 	ownerRef := opcontroller.ClusterOwnerRef(clusterInfo.Namespace, ownerRefID)
 	clusterInfo.OwnerRef = ownerRef
 	kv := k8sutil.NewConfigMapKVStore(clusterInfo.Namespace, context.Clientset, ownerRef)
-	agent := osddaemon.NewAgent(context, dgs, dataDevices, cfg.metadataDevice, forceFormat,
+	agent := osddaemon.NewAgent(context, dgs, dataDevices, cfg.metadataDevice, cfg.walDevice, forceFormat,
 		cfg.storeConfig, &clusterInfo, cfg.nodeName, kv, cfg.pvcBacked)
 
 	err = osddaemon.Provision(context, agent, crushLocation)
@@ -280,6 +317,7 @@ func parseDevices(devices string) ([]osddaemon.DesiredDevice, error) {
 		d.DatabaseSizeMB = cd.StoreConfig.DatabaseSizeMB
 		d.DeviceClass = cd.StoreConfig.DeviceClass
 		d.MetadataDevice = cd.StoreConfig.MetadataDevice
+		d.WalDevice = cd.StoreConfig.WalDevice
 
 		if d.OSDsPerDevice < 1 {
 			return nil, errors.Errorf("osds per device should be greater than 0 (%q)", d.OSDsPerDevice)
@@ -121,6 +121,7 @@ func TestParseDesiredDevices(t *testing.T) {
 			StoreConfig: osdcfg.StoreConfig{
 				OSDsPerDevice:  1,
 				MetadataDevice: "sdc",
+				WalDevice:      "nvme0n1",
 			},
 		},
 		{
@@ -157,6 +158,8 @@ func TestParseDesiredDevices(t *testing.T) {
 	assert.Equal(t, "sdb", result[1].MetadataDevice)
 	assert.Equal(t, "sdc", result[2].MetadataDevice)
 	assert.Equal(t, "sdc", result[3].MetadataDevice)
+	assert.Equal(t, "", result[0].WalDevice)
+	assert.Equal(t, "nvme0n1", result[2].WalDevice)
 	assert.False(t, result[0].IsFilter)
 	assert.False(t, result[1].IsFilter)
 	assert.False(t, result[2].IsFilter)
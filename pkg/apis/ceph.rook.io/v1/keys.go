@@ -17,6 +17,8 @@ limitations under the License.
 package v1
 
 import (
+	"fmt"
+
 	rook "github.com/rook/rook/pkg/apis/rook.io/v1"
 )
 
@@ -26,3 +28,9 @@ const (
 	KeyOSD     rook.KeyType = "osd"
 	KeyCleanup rook.KeyType = "cleanup"
 )
+
+// OSDDeviceClassKey returns the key of the placement and the resources of the OSDs of a crush
+// device class, such as "osd-hdd"
+func OSDDeviceClassKey(deviceClass string) string {
+	return fmt.Sprintf("%s-%s", KeyOSD, deviceClass)
+}
@@ -34,6 +34,16 @@ func GetOSDPlacement(p rookv1.PlacementSpec) rookv1.Placement {
 	return p.All().Merge(p[KeyOSD])
 }
 
+// GetOSDDeviceClassPlacement returns the placement for the OSDs of a crush device class. The
+// placement of the device class overrides the placement of the OSDs.
+func GetOSDDeviceClassPlacement(p rookv1.PlacementSpec, deviceClass string) rookv1.Placement {
+	placement := GetOSDPlacement(p)
+	if deviceClass == "" {
+		return placement
+	}
+	return placement.Merge(p[rookv1.KeyType(OSDDeviceClassKey(deviceClass))])
+}
+
 // GetCleanupPlacement returns the placement the cleanup job
 func GetCleanupPlacement(p rookv1.PlacementSpec) rookv1.Placement {
 	return p.All().Merge(p[KeyCleanup])
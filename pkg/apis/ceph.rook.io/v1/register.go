@@ -60,10 +60,14 @@ func addKnownTypes(scheme *runtime.Scheme) error {
 		&CephClusterList{},
 		&CephBlockPool{},
 		&CephBlockPoolList{},
+		&CephDashboardUser{},
+		&CephDashboardUserList{},
 		&CephFilesystem{},
 		&CephFilesystemList{},
 		&CephNFS{},
 		&CephNFSList{},
+		&CephOSDRemoval{},
+		&CephOSDRemovalList{},
 		&CephObjectStore{},
 		&CephObjectStoreList{},
 		&CephObjectStoreUser{},
@@ -55,6 +55,14 @@ func GetOSDResources(p rook.ResourceSpec) v1.ResourceRequirements {
 	return p[ResourcesKeyOSD]
 }
 
+// GetOSDDeviceClassResources returns the resources for the OSDs of a crush device class
+func GetOSDDeviceClassResources(p rook.ResourceSpec, deviceClass string) v1.ResourceRequirements {
+	if deviceClass == "" {
+		return v1.ResourceRequirements{}
+	}
+	return p[OSDDeviceClassKey(deviceClass)]
+}
+
 // GetPrepareOSDResources returns the placement for the OSDs prepare job
 func GetPrepareOSDResources(p rook.ResourceSpec) v1.ResourceRequirements {
 	return p[ResourcesKeyPrepareOSD]
@@ -126,6 +126,23 @@ type ClusterSpec struct {
 
 	// Internal daemon healthchecks and liveness probe
 	HealthCheck CephClusterHealthCheckSpec `json:"healthCheck"`
+
+	// Security represents security settings
+	Security SecuritySpec `json:"security,omitempty"`
+}
+
+// SecuritySpec represents the security settings of the cluster
+type SecuritySpec struct {
+	// KeyRotation is the policy to rotate the encryption keys of the encrypted OSDs
+	KeyRotation KeyRotationSpec `json:"keyRotation,omitempty"`
+}
+
+// KeyRotationSpec represents the settings of the periodic rotation of the OSD encryption keys
+type KeyRotationSpec struct {
+	// Enabled represents whether the encryption keys are rotated
+	Enabled bool `json:"enabled,omitempty"`
+	// Schedule is the cron schedule of the rotation, "@weekly" if not set
+	Schedule string `json:"schedule,omitempty"`
 }
 
 // VersionSpec represents the settings for the Ceph version that Rook is orchestrating.
@@ -166,6 +183,29 @@ type DashboardSpec struct {
 	Port int `json:"port,omitempty"`
 	// Whether SSL should be used
 	SSL bool `json:"ssl,omitempty"`
+	// The SAML2 single sign-on settings of the dashboard. If not set, the single sign-on
+	// configured outside of the cluster CR is left untouched.
+	SSO *DashboardSSOSpec `json:"sso,omitempty"`
+}
+
+// DashboardSSOSpec represents the SAML2 single sign-on settings of the dashboard
+type DashboardSSOSpec struct {
+	// Whether to enable the single sign-on, it is disabled otherwise
+	Enabled bool `json:"enabled,omitempty"`
+	// The URL of the dashboard as accessed by the users, the identity provider redirects them there
+	BaseURL string `json:"baseURL,omitempty"`
+	// The URL of the metadata of the identity provider. If not set, the metadata is read from the
+	// secret.
+	IdPMetadataURL string `json:"idpMetadataURL,omitempty"`
+	// The attribute of the identity provider response used as the dashboard user name, "uid" by default
+	IdPUsernameAttribute string `json:"idpUsernameAttribute,omitempty"`
+	// The entity ID of the identity provider, required if the metadata describes several entities
+	// or if the requests are signed
+	IdPEntityID string `json:"idpEntityID,omitempty"`
+	// The name of a secret with the metadata of the identity provider in its "idp-metadata.xml" key
+	// and the certificate and private key of the dashboard to sign the requests in its "tls.crt"
+	// and "tls.key" keys. All the keys are optional.
+	SecretName string `json:"secretName,omitempty"`
 }
 
 // MonitoringSpec represents the settings for Prometheus based Ceph monitoring
@@ -202,6 +242,20 @@ type CephStatus struct {
 
 type CephStorage struct {
 	DeviceClasses []DeviceClasses `json:"deviceClasses,omitempty"`
+	// KeyRotation is the status of the rotation of the encryption key of each encrypted OSD
+	KeyRotation []OSDKeyRotationStatus `json:"keyRotation,omitempty"`
+}
+
+// OSDKeyRotationStatus represents the status of the last rotation of the encryption key of an OSD
+type OSDKeyRotationStatus struct {
+	// PVC is the name of the PVC of the OSD
+	PVC string `json:"pvc,omitempty"`
+	// OSD is the ID of the OSD, or -1 if the OSD has no deployment
+	OSD int `json:"osd"`
+	// Status is the status of the last rotation
+	Status string `json:"status,omitempty"`
+	// LastRotation is the time of the last rotation
+	LastRotation string `json:"lastRotation,omitempty"`
 }
 
 type DeviceClasses struct {
@@ -230,15 +284,18 @@ type Condition struct {
 type ConditionType string
 
 const (
-	ConditionIgnored     ConditionType = "Ignored"
-	ConditionConnecting  ConditionType = "Connecting"
-	ConditionConnected   ConditionType = "Connected"
-	ConditionProgressing ConditionType = "Progressing"
-	ConditionReady       ConditionType = "Ready"
-	ConditionUpdating    ConditionType = "Updating"
-	ConditionFailure     ConditionType = "Failure"
-	ConditionUpgrading   ConditionType = "Upgrading"
-	ConditionDeleting    ConditionType = "Deleting"
+	ConditionIgnored       ConditionType = "Ignored"
+	ConditionConnecting    ConditionType = "Connecting"
+	ConditionConnected     ConditionType = "Connected"
+	ConditionProgressing   ConditionType = "Progressing"
+	ConditionReady         ConditionType = "Ready"
+	ConditionUpdating      ConditionType = "Updating"
+	ConditionFailure       ConditionType = "Failure"
+	ConditionUpgrading     ConditionType = "Upgrading"
+	ConditionDeleting      ConditionType = "Deleting"
+	ConditionPaused        ConditionType = "Paused"
+	ConditionDryRun        ConditionType = "DryRun"
+	ConditionMonQuorumRisk ConditionType = "MonQuorumRisk"
 	// DefaultFailureDomain for PoolSpec
 	DefaultFailureDomain = "host"
 )
@@ -257,6 +314,7 @@ const (
 type MonSpec struct {
 	Count                int                       `json:"count,omitempty"`
 	AllowMultiplePerNode bool                      `json:"allowMultiplePerNode,omitempty"`
+	AllowEvenCount       bool                      `json:"allowEvenCount,omitempty"`
 	VolumeClaimTemplate  *v1.PersistentVolumeClaim `json:"volumeClaimTemplate,omitempty"`
 }
 
@@ -269,6 +327,8 @@ type MgrSpec struct {
 type Module struct {
 	Name    string `json:"name,omitempty"`
 	Enabled bool   `json:"enabled"`
+	// Settings are the options of the module, set as "mgr/<module>/<option>" in the ceph config
+	Settings map[string]string `json:"settings,omitempty"`
 }
 
 // ExternalSpec represents the options supported by an external cluster
@@ -779,3 +839,97 @@ type RBDMirroringSpec struct {
 	// PriorityClassName sets priority class on the rbd mirror pods
 	PriorityClassName string `json:"priorityClassName,omitempty"`
 }
+
+// +genclient
+// +genclient:noStatus
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// CephOSDRemoval is a request to remove OSDs from the cluster
+type CephOSDRemoval struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata"`
+	Spec              OSDRemovalSpec    `json:"spec"`
+	Status            *OSDRemovalStatus `json:"status,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+type CephOSDRemovalList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata"`
+	Items           []CephOSDRemoval `json:"items"`
+}
+
+// OSDRemovalSpec represents the OSDs to remove
+type OSDRemovalSpec struct {
+	// OSDIDs are the IDs of the OSDs to remove
+	OSDIDs []int `json:"osdIDs,omitempty"`
+
+	// NodeName is the name of a node whose OSDs are all removed
+	NodeName string `json:"nodeName,omitempty"`
+}
+
+// OSDRemovalStatus represents the progress of the removal of the OSDs
+type OSDRemovalStatus struct {
+	Phase   string `json:"phase,omitempty"`
+	Message string `json:"message,omitempty"`
+	// OSDs are the OSDs being removed with their removal state
+	OSDs []OSDRemovalState `json:"osds,omitempty"`
+	// CleanPGs is the number of active+clean PGs while the data is drained from the OSDs
+	CleanPGs int `json:"cleanPGs,omitempty"`
+	// TotalPGs is the number of PGs in the cluster
+	TotalPGs int `json:"totalPGs,omitempty"`
+}
+
+// OSDRemovalState is the removal state of an OSD
+type OSDRemovalState struct {
+	ID    int    `json:"id"`
+	State string `json:"state"`
+}
+
+// +genclient
+// +genclient:noStatus
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// CephDashboardUser is a user of the Ceph dashboard
+type CephDashboardUser struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata"`
+	Spec              DashboardUserSpec    `json:"spec"`
+	Status            *DashboardUserStatus `json:"status,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+type CephDashboardUserList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata"`
+	Items           []CephDashboardUser `json:"items"`
+}
+
+// DashboardUserSpec represents the settings of a dashboard user
+type DashboardUserSpec struct {
+	// Username is the name the user logs in with, the name of the CR by default
+	Username string `json:"username,omitempty"`
+
+	// PasswordSecret is the name of a secret with the password of the user in its "password" key
+	PasswordSecret string `json:"passwordSecret"`
+
+	// Roles are the dashboard roles of the user, such as "administrator" or "read-only"
+	Roles []string `json:"roles,omitempty"`
+
+	// Name is the full name of the user
+	Name string `json:"name,omitempty"`
+
+	// Email is the email address of the user
+	Email string `json:"email,omitempty"`
+}
+
+// DashboardUserStatus represents the status of a dashboard user
+type DashboardUserStatus struct {
+	Phase string `json:"phase,omitempty"`
+	// Username is the name of the dashboard user last created for the CR
+	Username string `json:"username,omitempty"`
+	// PasswordSecretVersion is the resource version of the password secret last set on the user
+	PasswordSecretVersion string `json:"passwordSecretVersion,omitempty"`
+}
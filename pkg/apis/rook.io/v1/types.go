@@ -34,6 +34,14 @@ type StorageScopeSpec struct {
 	Selection
 	VolumeSources          []VolumeSource          `json:"volumeSources,omitempty"`
 	StorageClassDeviceSets []StorageClassDeviceSet `json:"storageClassDeviceSets"`
+	// ProvisioningConcurrency is the number of nodes and PVCs on which OSDs are provisioned at the
+	// same time. Defaults to 1 when not set.
+	ProvisioningConcurrency int `json:"provisioningConcurrency,omitempty"`
+	// AllowOsdReplace enables the automatic replacement of the OSDs whose device was physically
+	// swapped for a new device
+	AllowOsdReplace bool `json:"allowOsdReplace,omitempty"`
+	// AutotuneMemoryTarget sets the osd_memory_target of each OSD from the memory limit of its pod
+	AutotuneMemoryTarget bool `json:"autotuneMemoryTarget,omitempty"`
 }
 
 type Node struct {
@@ -29,8 +29,10 @@ type CephV1Interface interface {
 	CephBlockPoolsGetter
 	CephClientsGetter
 	CephClustersGetter
+	CephDashboardUsersGetter
 	CephFilesystemsGetter
 	CephNFSesGetter
+	CephOSDRemovalsGetter
 	CephObjectRealmsGetter
 	CephObjectStoresGetter
 	CephObjectStoreUsersGetter
@@ -56,6 +58,10 @@ func (c *CephV1Client) CephClusters(namespace string) CephClusterInterface {
 	return newCephClusters(c, namespace)
 }
 
+func (c *CephV1Client) CephDashboardUsers(namespace string) CephDashboardUserInterface {
+	return newCephDashboardUsers(c, namespace)
+}
+
 func (c *CephV1Client) CephFilesystems(namespace string) CephFilesystemInterface {
 	return newCephFilesystems(c, namespace)
 }
@@ -64,6 +70,10 @@ func (c *CephV1Client) CephNFSes(namespace string) CephNFSInterface {
 	return newCephNFSes(c, namespace)
 }
 
+func (c *CephV1Client) CephOSDRemovals(namespace string) CephOSDRemovalInterface {
+	return newCephOSDRemovals(c, namespace)
+}
+
 func (c *CephV1Client) CephObjectRealms(namespace string) CephObjectRealmInterface {
 	return newCephObjectRealms(c, namespace)
 }
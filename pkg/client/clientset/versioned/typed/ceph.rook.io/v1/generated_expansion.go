@@ -24,10 +24,14 @@ type CephClientExpansion interface{}
 
 type CephClusterExpansion interface{}
 
+type CephDashboardUserExpansion interface{}
+
 type CephFilesystemExpansion interface{}
 
 type CephNFSExpansion interface{}
 
+type CephOSDRemovalExpansion interface{}
+
 type CephObjectRealmExpansion interface{}
 
 type CephObjectStoreExpansion interface{}
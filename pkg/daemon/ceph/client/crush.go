@@ -184,3 +184,13 @@ func GetOSDOnHost(context *clusterd.Context, clusterInfo *ClusterInfo, node stri
 
 	return string(buf), nil
 }
+
+// CrushReweight sets the crush weight of the osd, the weight is expressed in TiB
+func CrushReweight(context *clusterd.Context, clusterInfo *ClusterInfo, osdID int, weight float64) error {
+	args := []string{"osd", "crush", "reweight", fmt.Sprintf("osd.%d", osdID), strconv.FormatFloat(weight, 'f', 5, 64)}
+	buf, err := NewCephCommand(context, clusterInfo, args).Run()
+	if err != nil {
+		return errors.Wrapf(err, "failed to reweight osd.%d to %v. %s", osdID, weight, string(buf))
+	}
+	return nil
+}
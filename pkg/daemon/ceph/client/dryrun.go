@@ -0,0 +1,121 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"strings"
+)
+
+var (
+	// the tools which connect to the ceph cluster and may change its state
+	clusterTools = map[string]bool{CephTool: true, RBDTool: true, "rados": true, "radosgw-admin": true}
+
+	// the commands that only read the state of the cluster, per tool. A command is read-only if its
+	// leading words are exactly one of these prefixes, the remaining words being arguments like the
+	// name of a pool. Any other command is considered to change the cluster.
+	readOnlyCommands = map[string][]string{
+		CephTool: {
+			"status", "health", "health detail", "df", "df detail", "version", "versions", "report",
+			"quorum_status", "mon_status", "mon dump", "mon stat", "mon metadata", "mon ok-to-stop",
+			"osd dump", "osd tree", "osd df", "osd ls", "osd lspools", "osd find", "osd perf", "osd stat",
+			"osd metadata", "osd versions", "osd ok-to-stop", "osd safe-to-destroy", "osd blacklist ls",
+			"osd crush dump", "osd crush ls", "osd crush class ls", "osd crush rule ls", "osd crush rule dump",
+			"osd pool ls", "osd pool get", "osd pool stats", "osd pool autoscale-status",
+			"osd erasure-code-profile get", "osd erasure-code-profile ls",
+			"pg dump", "pg stat", "auth get", "auth get-key", "auth ls", "config get", "config dump",
+			"config-key get", "config-key ls", "fs ls", "fs get", "fs dump", "fs status", "mds stat",
+			"mgr dump", "mgr stat", "mgr metadata", "mgr versions", "mgr services", "mgr module ls",
+			"balancer status", "crash ls", "device ls", "time-sync-status",
+			"dashboard ac-user-show", "dashboard get-rgw-api-access-key", "dashboard get-rgw-api-secret-key",
+		},
+		RBDTool: {
+			"ls", "info", "status", "du", "mirror pool info", "mirror pool status", "mirror image status",
+		},
+		"rados": {
+			"lspools", "df",
+		},
+		"radosgw-admin": {
+			"user info", "user list", "bucket list", "bucket stats", "realm get", "realm list",
+			"zonegroup get", "zonegroup list", "zone get", "zone list", "period get",
+		},
+	}
+)
+
+// IsReadOnlyCommand returns whether the command only reads the state of the ceph cluster. The
+// commands of tools that don't connect to the cluster (e.g. ceph-authtool or crushtool) are
+// considered read-only since they only act on local files.
+func IsReadOnlyCommand(command string, args ...string) bool {
+	// commands which are run in the toolbox are wrapped in a kubectl exec
+	if command == Kubectl {
+		for i, arg := range args {
+			if arg == "--" && i+1 < len(args) {
+				command, args = args[i+1], args[i+2:]
+				break
+			}
+		}
+	}
+	if !clusterTools[command] {
+		return true
+	}
+
+	words := commandWords(args)
+	for _, prefix := range readOnlyCommands[command] {
+		if hasWordsPrefix(words, strings.Fields(prefix)) {
+			return true
+		}
+	}
+	return false
+}
+
+// hasWordsPrefix returns whether the words start with all the words of the prefix
+func hasWordsPrefix(words, prefix []string) bool {
+	if len(words) < len(prefix) {
+		return false
+	}
+	for i := range prefix {
+		if words[i] != prefix[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// CommandClusterNamespace returns the namespace of the cluster a ceph command is run against
+func CommandClusterNamespace(args ...string) string {
+	for _, arg := range args {
+		if strings.HasPrefix(arg, "--cluster=") {
+			return strings.TrimPrefix(arg, "--cluster=")
+		}
+	}
+	return ""
+}
+
+// CommandString returns the command line without the flags, e.g. "ceph osd pool create replicapool 0"
+func CommandString(command string, args ...string) string {
+	return strings.Join(append([]string{command}, commandWords(args)...), " ")
+}
+
+// commandWords returns the arguments of the command which are not flags
+func commandWords(args []string) []string {
+	words := []string{}
+	for _, arg := range args {
+		if !strings.HasPrefix(arg, "-") {
+			words = append(words, arg)
+		}
+	}
+	return words
+}
@@ -0,0 +1,53 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsReadOnlyCommand(t *testing.T) {
+	assert.True(t, IsReadOnlyCommand("ceph", "status", "--format", "json", "--cluster=rook-ceph"))
+	assert.True(t, IsReadOnlyCommand("ceph", "osd", "pool", "get", "replicapool", "all"))
+	assert.True(t, IsReadOnlyCommand("ceph", "mon", "dump"))
+	assert.True(t, IsReadOnlyCommand("radosgw-admin", "user", "info", "--uid=foo"))
+	assert.False(t, IsReadOnlyCommand("ceph", "osd", "pool", "create", "replicapool", "0"))
+	assert.False(t, IsReadOnlyCommand("ceph", "auth", "get-or-create-key", "client.admin"))
+	assert.False(t, IsReadOnlyCommand("rbd", "create", "replicapool/image"))
+	assert.False(t, IsReadOnlyCommand("ceph", "osd", "pool", "set", "replicapool", "size", "3"))
+
+	// the names chosen by the users are not mistaken for read-only commands
+	assert.False(t, IsReadOnlyCommand("ceph", "osd", "pool", "delete", "list", "list", "--yes-i-really-really-mean-it"))
+	assert.False(t, IsReadOnlyCommand("ceph", "fs", "rm", "info", "--yes-i-really-mean-it"))
+	assert.False(t, IsReadOnlyCommand("ceph", "auth", "del", "client.get"))
+	assert.False(t, IsReadOnlyCommand("radosgw-admin", "user", "rm", "--uid=info"))
+
+	// tools which don't connect to the cluster
+	assert.True(t, IsReadOnlyCommand("ceph-authtool", "--create-keyring", "/tmp/keyring"))
+
+	// commands run in the toolbox
+	assert.True(t, IsReadOnlyCommand(Kubectl, "exec", "-i", "rook-ceph-tools", "-n", "rook-ceph", "--", "ceph", "status"))
+	assert.False(t, IsReadOnlyCommand(Kubectl, "exec", "-i", "rook-ceph-tools", "-n", "rook-ceph", "--", "ceph", "osd", "out", "1"))
+}
+
+func TestCommandClusterNamespace(t *testing.T) {
+	assert.Equal(t, "rook-ceph", CommandClusterNamespace("osd", "out", "1", "--cluster=rook-ceph", "--name=client.admin"))
+	assert.Equal(t, "", CommandClusterNamespace("osd", "out", "1"))
+	assert.Equal(t, "ceph osd out 1", CommandString("ceph", "osd", "out", "1", "--cluster=rook-ceph", "--name=client.admin"))
+}
@@ -147,6 +147,7 @@ type OsdTree struct {
 		Name        string `json:"name"`
 		Type        string `json:"type"`
 		TypeID      int    `json:"type_id"`
+		DeviceClass string `json:"device_class,omitempty"`
 		Children    []int  `json:"children,omitempty"`
 		PoolWeights struct {
 		} `json:"pool_weights,omitempty"`
@@ -252,6 +253,15 @@ func OSDOut(context *clusterd.Context, clusterInfo *ClusterInfo, osdID int) (str
 	return string(buf), err
 }
 
+// PurgeOSD removes the OSD from the crush map, deletes its auth key and removes it from the osd map
+func PurgeOSD(context *clusterd.Context, clusterInfo *ClusterInfo, osdID int) error {
+	args := []string{"osd", "purge", strconv.Itoa(osdID), "--yes-i-really-mean-it"}
+	if _, err := NewCephCommand(context, clusterInfo, args).Run(); err != nil {
+		return errors.Wrapf(err, "failed to purge osd.%d", osdID)
+	}
+	return nil
+}
+
 func OsdSafeToDestroy(context *clusterd.Context, clusterInfo *ClusterInfo, osdID int) (bool, error) {
 	args := []string{"osd", "safe-to-destroy", strconv.Itoa(osdID)}
 	cmd := NewCephCommand(context, clusterInfo, args)
@@ -35,6 +35,7 @@ type OsdAgent struct {
 	driveGroups    config.DriveGroupBlobs
 	devices        []DesiredDevice
 	metadataDevice string
+	walDevice      string
 	storeConfig    config.StoreConfig
 	kv             *k8sutil.ConfigMapKVStore
 	pvcBacked      bool
@@ -48,13 +49,14 @@ type device struct {
 }
 
 // NewAgent is the instantiation of the OSD agent
-func NewAgent(context *clusterd.Context, driveGroups config.DriveGroupBlobs, devices []DesiredDevice, metadataDevice string, forceFormat bool,
+func NewAgent(context *clusterd.Context, driveGroups config.DriveGroupBlobs, devices []DesiredDevice, metadataDevice, walDevice string, forceFormat bool,
 	storeConfig config.StoreConfig, clusterInfo *cephclient.ClusterInfo, nodeName string, kv *k8sutil.ConfigMapKVStore, pvcBacked bool) *OsdAgent {
 
 	return &OsdAgent{
 		driveGroups:    driveGroups,
 		devices:        devices,
 		metadataDevice: metadataDevice,
+		walDevice:      walDevice,
 		forceFormat:    forceFormat,
 		storeConfig:    storeConfig,
 		clusterInfo:    clusterInfo,
@@ -31,6 +31,7 @@ import (
 	"github.com/rook/rook/pkg/clusterd"
 	cephclient "github.com/rook/rook/pkg/daemon/ceph/client"
 	oposd "github.com/rook/rook/pkg/operator/ceph/cluster/osd"
+	"github.com/rook/rook/pkg/operator/ceph/cluster/osd/config"
 	"github.com/rook/rook/pkg/util/sys"
 )
 
@@ -311,6 +312,50 @@ func Provision(context *clusterd.Context, agent *OsdAgent, crushLocation string)
 	return nil
 }
 
+// isDesiredByPath returns whether the device is listed by one of its paths in the desired devices,
+// the device filters don't count
+func isDesiredByPath(device *sys.LocalDisk, desiredDevices []DesiredDevice) bool {
+	for _, desiredDevice := range desiredDevices {
+		if desiredDevice.IsFilter || desiredDevice.IsDevicePathFilter || !strings.HasPrefix(desiredDevice.Name, "/dev/") {
+			continue
+		}
+		if desiredDevice.Name == device.RealPath {
+			return true
+		}
+		for _, link := range strings.Fields(device.DevLinks) {
+			if link == desiredDevice.Name {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// deviceHealthRejectedReason returns why the device must not be provisioned because of its health,
+// or an empty string if it can be provisioned. The devices whose health cannot be read are
+// provisioned.
+func deviceHealthRejectedReason(context *clusterd.Context, storeConfig config.StoreConfig, device *sys.LocalDisk) string {
+	if !storeConfig.SkipUnhealthyDevices && storeConfig.MaxMediaWearout <= 0 {
+		return ""
+	}
+
+	health, err := sys.GetDeviceHealth(device.RealPath, context.Executor)
+	if err != nil {
+		logger.Warningf("failed to get the health of device %q, assuming it is healthy. %v", device.Name, err)
+		return ""
+	}
+	if health == nil {
+		return ""
+	}
+	if storeConfig.SkipUnhealthyDevices && !health.Passed {
+		return "the device failed its SMART health self-assessment"
+	}
+	if storeConfig.MaxMediaWearout > 0 && health.MediaWearout >= storeConfig.MaxMediaWearout {
+		return fmt.Sprintf("the media wearout of the device %d%% reached the maximum of %d%%", health.MediaWearout, storeConfig.MaxMediaWearout)
+	}
+	return ""
+}
+
 func getAvailableDevices(context *clusterd.Context, agent *OsdAgent) (*DeviceOsdMapping, error) {
 	desiredDevices := agent.devices
 	logger.Debugf("desiredDevices are %+v", desiredDevices)
@@ -320,7 +365,9 @@ func getAvailableDevices(context *clusterd.Context, agent *OsdAgent) (*DeviceOsd
 	for _, device := range context.Devices {
 		// Ignore 'dm' device since they are not handled by c-v properly
 		// see: https://tracker.ceph.com/issues/43209
-		if strings.HasPrefix(device.Name, sys.DeviceMapperPrefix) && device.Type == sys.LVMType {
+		// Unless the LV is listed by path, it is then prepared with 'ceph-volume lvm prepare'
+		isLV := strings.HasPrefix(device.Name, sys.DeviceMapperPrefix) && device.Type == sys.LVMType
+		if isLV && !isDesiredByPath(device, desiredDevices) {
 			logger.Infof("skipping 'dm' device %q", device.Name)
 			continue
 		}
@@ -374,10 +421,14 @@ func getAvailableDevices(context *clusterd.Context, agent *OsdAgent) (*DeviceOsd
 				isAvailable = true
 			}
 		} else {
-			isAvailable, rejectedReason, err = sys.CheckIfDeviceAvailable(context.Executor, device.RealPath, agent.pvcBacked)
+			isAvailable, rejectedReason, err = sys.CheckIfDeviceAvailable(context.Executor, device.RealPath, isLV)
 			if err != nil {
 				return nil, errors.Wrapf(err, "failed to get device %q info", device.Name)
 			}
+			if isAvailable {
+				rejectedReason = deviceHealthRejectedReason(context, agent.storeConfig, device)
+				isAvailable = rejectedReason == ""
+			}
 		}
 
 		if !isAvailable {
@@ -390,10 +441,10 @@ func getAvailableDevices(context *clusterd.Context, agent *OsdAgent) (*DeviceOsd
 		var deviceInfo *DeviceOsdIDEntry
 		if agent.metadataDevice != "" && agent.metadataDevice == device.Name {
 			// current device is desired as the metadata device
-			deviceInfo = &DeviceOsdIDEntry{Data: unassignedOSDID, Metadata: []int{}}
+			deviceInfo = &DeviceOsdIDEntry{Data: unassignedOSDID, Metadata: []int{}, DeviceInfo: device}
 		} else if len(desiredDevices) == 1 && desiredDevices[0].Name == "all" {
 			// user has specified all devices, use the current one for data
-			deviceInfo = &DeviceOsdIDEntry{Data: unassignedOSDID}
+			deviceInfo = &DeviceOsdIDEntry{Data: unassignedOSDID, DeviceInfo: device}
 		} else if len(desiredDevices) > 0 {
 			var matched bool
 			var matchedDevice DesiredDevice
@@ -426,6 +477,9 @@ func getAvailableDevices(context *clusterd.Context, agent *OsdAgent) (*DeviceOsd
 				} else if device.Name == desiredDevice.Name {
 					logger.Infof("%q found in the desired devices", device.Name)
 					matched = true
+				} else if strings.HasPrefix(desiredDevice.Name, "/dev/") && desiredDevice.Name == device.RealPath {
+					logger.Infof("%q found in the desired devices (matched by path)", device.Name)
+					matched = true
 				} else if strings.HasPrefix(desiredDevice.Name, "/dev/") {
 					devLinks := strings.Split(device.DevLinks, " ")
 					for _, link := range devLinks {
@@ -446,7 +500,7 @@ func getAvailableDevices(context *clusterd.Context, agent *OsdAgent) (*DeviceOsd
 			if err == nil && matched {
 				// the current device matches the user specifies filter/list, use it for data
 				logger.Infof("device %q is selected by the device filter/name %q", device.Name, matchedDevice.Name)
-				deviceInfo = &DeviceOsdIDEntry{Data: unassignedOSDID, Config: matchedDevice, PersistentDevicePaths: strings.Fields(device.DevLinks)}
+				deviceInfo = &DeviceOsdIDEntry{Data: unassignedOSDID, Config: matchedDevice, PersistentDevicePaths: strings.Fields(device.DevLinks), DeviceInfo: device}
 
 				// set that this is not an OSD but a metadata device
 				if device.Type == pvcMetadataTypeDevice {
@@ -22,6 +22,7 @@ import (
 	"github.com/pkg/errors"
 	"github.com/rook/rook/pkg/clusterd"
 	cephclient "github.com/rook/rook/pkg/daemon/ceph/client"
+	"github.com/rook/rook/pkg/operator/ceph/cluster/osd/config"
 	cephver "github.com/rook/rook/pkg/operator/ceph/version"
 	exectest "github.com/rook/rook/pkg/util/exec/test"
 	"github.com/rook/rook/pkg/util/sys"
@@ -378,6 +379,65 @@ NAME="sdb1" SIZE="30" TYPE="part" PKNAME="sdb"`, nil
 	assert.Equal(t, 1, len(mapping.Entries), mapping)
 }
 
+func TestIsDesiredByPath(t *testing.T) {
+	lv := &sys.LocalDisk{Name: "dm-0", RealPath: "/dev/mapper/vg1-lv1", DevLinks: "/dev/vg1/lv1 /dev/disk/by-id/dm-name-vg1-lv1", Type: sys.LVMType}
+
+	assert.True(t, isDesiredByPath(lv, []DesiredDevice{{Name: "sda"}, {Name: "/dev/vg1/lv1"}}))
+	assert.True(t, isDesiredByPath(lv, []DesiredDevice{{Name: "/dev/mapper/vg1-lv1"}}))
+	assert.False(t, isDesiredByPath(lv, []DesiredDevice{{Name: "/dev/vg1/lv2"}}))
+	assert.False(t, isDesiredByPath(lv, []DesiredDevice{{Name: "all"}}))
+	assert.False(t, isDesiredByPath(lv, []DesiredDevice{{Name: "dm-0"}}))
+
+	// the lvs are not selected by filters
+	assert.False(t, isDesiredByPath(lv, []DesiredDevice{{Name: "^dm-.*", IsFilter: true}}))
+	assert.False(t, isDesiredByPath(lv, []DesiredDevice{{Name: "^/dev/vg1/.*", IsDevicePathFilter: true}}))
+}
+
+func TestDeviceHealthRejectedReason(t *testing.T) {
+	smartOutput := map[string]string{
+		"/dev/sda":     `{"smart_status":{"passed":true},"ata_smart_attributes":{"table":[{"id":5,"value":100,"raw":{"value":0}},{"id":177,"value":95,"raw":{"value":12}}]}}`,
+		"/dev/sdb":     `{"smart_status":{"passed":false},"ata_smart_attributes":{"table":[{"id":5,"value":80,"raw":{"value":120}}]}}`,
+		"/dev/nvme0n1": `{"smart_status":{"passed":true},"nvme_smart_health_information_log":{"percentage_used":92,"media_errors":0}}`,
+		"/dev/vda":     `{"device":{"name":"/dev/vda"}}`,
+	}
+	calls := 0
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithCombinedOutput: func(command string, args ...string) (string, error) {
+			calls++
+			if command == "smartctl" {
+				if output, ok := smartOutput[args[2]]; ok {
+					return output, nil
+				}
+			}
+			return "", errors.Errorf("unknown command %s %s", command, args)
+		},
+	}
+	context := &clusterd.Context{Executor: executor}
+	sda := &sys.LocalDisk{Name: "sda", RealPath: "/dev/sda"}
+	sdb := &sys.LocalDisk{Name: "sdb", RealPath: "/dev/sdb"}
+	nvme := &sys.LocalDisk{Name: "nvme0n1", RealPath: "/dev/nvme0n1"}
+	vda := &sys.LocalDisk{Name: "vda", RealPath: "/dev/vda"}
+	sdc := &sys.LocalDisk{Name: "sdc", RealPath: "/dev/sdc"}
+
+	// the health is not checked by default
+	assert.Equal(t, "", deviceHealthRejectedReason(context, config.StoreConfig{}, sdb))
+	assert.Equal(t, 0, calls)
+
+	storeConfig := config.StoreConfig{SkipUnhealthyDevices: true}
+	assert.Equal(t, "", deviceHealthRejectedReason(context, storeConfig, sda))
+	assert.NotEqual(t, "", deviceHealthRejectedReason(context, storeConfig, sdb))
+	assert.Equal(t, "", deviceHealthRejectedReason(context, storeConfig, nvme))
+
+	// the devices without health data or whose health cannot be read are accepted
+	assert.Equal(t, "", deviceHealthRejectedReason(context, storeConfig, vda))
+	assert.Equal(t, "", deviceHealthRejectedReason(context, storeConfig, sdc))
+
+	storeConfig = config.StoreConfig{MaxMediaWearout: 90}
+	assert.Equal(t, "", deviceHealthRejectedReason(context, storeConfig, sda))
+	assert.Equal(t, "", deviceHealthRejectedReason(context, storeConfig, sdb))
+	assert.NotEqual(t, "", deviceHealthRejectedReason(context, storeConfig, nvme))
+}
+
 func TestGetVolumeGroupName(t *testing.T) {
 	validLVPath := "/dev/vgName1/lvName2"
 	invalidLVPath1 := "/dev//vgName2"
@@ -28,6 +28,7 @@ import (
 	cephclient "github.com/rook/rook/pkg/daemon/ceph/client"
 	"github.com/rook/rook/pkg/operator/ceph/cluster/osd/config"
 	"github.com/rook/rook/pkg/operator/k8sutil"
+	"github.com/rook/rook/pkg/util/sys"
 )
 
 const (
@@ -67,6 +68,7 @@ type DesiredDevice struct {
 	Name               string
 	OSDsPerDevice      int
 	MetadataDevice     string
+	WalDevice          string
 	DatabaseSizeMB     int
 	DeviceClass        string
 	IsFilter           bool
@@ -84,6 +86,7 @@ type DeviceOsdIDEntry struct {
 	Metadata              []int         // OSD IDs (multiple) that have metadata stored here
 	Config                DesiredDevice // Device specific config options
 	PersistentDevicePaths []string
+	DeviceInfo            *sys.LocalDisk // The device as discovered on the node
 }
 
 type devicePartInfo struct {
@@ -17,12 +17,29 @@ limitations under the License.
 package osd
 
 import (
+	"crypto/rand"
+	"encoding/base64"
+	"io/ioutil"
+	"os"
+	"time"
+
 	"github.com/pkg/errors"
 	"github.com/rook/rook/pkg/clusterd"
+	oposd "github.com/rook/rook/pkg/operator/ceph/cluster/osd"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 const (
 	cryptsetupBinary = "cryptsetup"
+
+	encryptionKeySize = 128
+
+	// the new key is saved in the secret under this name before it is added to the encrypted blocks
+	// so that an interrupted rotation resumes with the same key
+	pendingEncryptionKeyName = "dmcrypt-key-pending"
+	// the replaced key is kept in the secret under this name until it is removed from the encrypted blocks
+	previousEncryptionKeyName = "dmcrypt-key-previous"
 )
 
 func closeEncryptedDevice(context *clusterd.Context, dmName string) error {
@@ -35,3 +52,174 @@ func closeEncryptedDevice(context *clusterd.Context, dmName string) error {
 	logger.Info(cryptsetupOut)
 	return nil
 }
+
+// RotateEncryptionKey replaces the key of the encrypted blocks of an OSD with a new random key and
+// saves it in the secret of the OSD. The rotation goes through steps recorded in the secret so that
+// the OSD can open its blocks with the key of the secret at any time: the new key is added to the
+// blocks before it replaces the key of the secret, and the old key is removed from the blocks once
+// the secret is updated. An interrupted rotation is resumed from the last step.
+func RotateEncryptionKey(context *clusterd.Context, namespace, secretName, keyName string, blocks []string) error {
+	err := rotateEncryptionKey(context, namespace, secretName, keyName, blocks)
+	status := oposd.KeyRotationSucceeded
+	if err != nil {
+		status = oposd.KeyRotationFailed
+	}
+	if statusErr := updateKeyRotationStatus(context, namespace, secretName, status); statusErr != nil {
+		logger.Errorf("failed to update the key rotation status of secret %q. %v", secretName, statusErr)
+	}
+	return err
+}
+
+func rotateEncryptionKey(context *clusterd.Context, namespace, secretName, keyName string, blocks []string) error {
+	secrets := context.Clientset.CoreV1().Secrets(namespace)
+	secret, err := secrets.Get(secretName, metav1.GetOptions{})
+	if err != nil {
+		return errors.Wrapf(err, "failed to get encryption key secret %q", secretName)
+	}
+	if len(secret.Data[keyName]) == 0 {
+		return errors.Errorf("no encryption key %q in secret %q", keyName, secretName)
+	}
+
+	if _, ok := secret.Data[previousEncryptionKeyName]; !ok {
+		if _, ok := secret.Data[pendingEncryptionKeyName]; !ok {
+			logger.Infof("generating a new encryption key for secret %q", secretName)
+			key, err := generateEncryptionKey()
+			if err != nil {
+				return err
+			}
+			secret.Data[pendingEncryptionKeyName] = key
+			if secret, err = secrets.Update(secret); err != nil {
+				return errors.Wrapf(err, "failed to save the new encryption key in secret %q", secretName)
+			}
+		}
+	}
+
+	if pendingKey, ok := secret.Data[pendingEncryptionKeyName]; ok {
+		for _, block := range blocks {
+			if err := addEncryptionKey(context, block, secret.Data[keyName], pendingKey); err != nil {
+				return err
+			}
+		}
+		secret.Data[previousEncryptionKeyName] = secret.Data[keyName]
+		secret.Data[keyName] = pendingKey
+		delete(secret.Data, pendingEncryptionKeyName)
+		if secret, err = secrets.Update(secret); err != nil {
+			return errors.Wrapf(err, "failed to replace the encryption key of secret %q", secretName)
+		}
+		logger.Infof("replaced the encryption key of secret %q", secretName)
+	}
+
+	if previousKey, ok := secret.Data[previousEncryptionKeyName]; ok {
+		for _, block := range blocks {
+			if err := removeEncryptionKey(context, block, secret.Data[keyName], previousKey); err != nil {
+				return err
+			}
+		}
+		delete(secret.Data, previousEncryptionKeyName)
+		if _, err = secrets.Update(secret); err != nil {
+			return errors.Wrapf(err, "failed to delete the previous encryption key from secret %q", secretName)
+		}
+	}
+
+	logger.Infof("rotated the encryption key of %d block(s)", len(blocks))
+	return nil
+}
+
+// addEncryptionKey adds the new key to a free key slot of the block unless the key already opens it
+func addEncryptionKey(context *clusterd.Context, block string, key, newKey []byte) error {
+	return withKeyFiles(func(keyFiles []string) error {
+		if keyOpensBlock(context, block, keyFiles[1]) {
+			logger.Infof("the new encryption key was already added to block %q", block)
+			return nil
+		}
+		logger.Infof("adding the new encryption key to block %q", block)
+		args := []string{"--verbose", "luksAddKey", "--key-file", keyFiles[0], block, keyFiles[1]}
+		if out, err := context.Executor.ExecuteCommandWithCombinedOutput(cryptsetupBinary, args...); err != nil {
+			return errors.Wrapf(err, "failed to add the new encryption key to block %q. %s", block, out)
+		}
+		return nil
+	}, key, newKey)
+}
+
+// removeEncryptionKey removes the previous key from the block once the current key is verified to
+// open the block, as the OSD does when it starts
+func removeEncryptionKey(context *clusterd.Context, block string, key, previousKey []byte) error {
+	return withKeyFiles(func(keyFiles []string) error {
+		if !keyOpensBlock(context, block, keyFiles[0]) {
+			return errors.Errorf("the encryption key of the secret does not open block %q, not removing the previous key", block)
+		}
+		if !keyOpensBlock(context, block, keyFiles[1]) {
+			logger.Infof("the previous encryption key was already removed from block %q", block)
+			return nil
+		}
+		logger.Infof("removing the previous encryption key from block %q", block)
+		args := []string{"--verbose", "luksRemoveKey", block, keyFiles[1]}
+		if out, err := context.Executor.ExecuteCommandWithCombinedOutput(cryptsetupBinary, args...); err != nil {
+			return errors.Wrapf(err, "failed to remove the previous encryption key from block %q. %s", block, out)
+		}
+		return nil
+	}, key, previousKey)
+}
+
+// keyOpensBlock returns whether the key of the file opens one of the key slots of the block
+func keyOpensBlock(context *clusterd.Context, block, keyFile string) bool {
+	args := []string{"luksOpen", "--test-passphrase", "--key-file", keyFile, block}
+	if out, err := context.Executor.ExecuteCommandWithCombinedOutput(cryptsetupBinary, args...); err != nil {
+		logger.Debugf("key file %q does not open block %q. %v. %s", keyFile, block, err, out)
+		return false
+	}
+	return true
+}
+
+// withKeyFiles writes the keys to temporary files that are deleted once the function returns
+func withKeyFiles(f func([]string) error, keys ...[]byte) error {
+	keyFiles := []string{}
+	defer func() {
+		for _, keyFile := range keyFiles {
+			if err := os.Remove(keyFile); err != nil {
+				logger.Warningf("failed to remove key file %q. %v", keyFile, err)
+			}
+		}
+	}()
+	for _, key := range keys {
+		file, err := ioutil.TempFile("", "luks-key")
+		if err != nil {
+			return errors.Wrap(err, "failed to create key file")
+		}
+		keyFiles = append(keyFiles, file.Name())
+		_, err = file.Write(key)
+		if closeErr := file.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			return errors.Wrap(err, "failed to write key file")
+		}
+	}
+	return f(keyFiles)
+}
+
+func generateEncryptionKey() ([]byte, error) {
+	key := make([]byte, encryptionKeySize)
+	if _, err := rand.Read(key); err != nil {
+		return nil, errors.Wrap(err, "failed to generate random bytes")
+	}
+	return []byte(base64.StdEncoding.EncodeToString(key)), nil
+}
+
+func updateKeyRotationStatus(context *clusterd.Context, namespace, secretName, status string) error {
+	secret, err := context.Clientset.CoreV1().Secrets(namespace).Get(secretName, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	setKeyRotationStatus(secret, status, time.Now())
+	_, err = context.Clientset.CoreV1().Secrets(namespace).Update(secret)
+	return err
+}
+
+func setKeyRotationStatus(secret *v1.Secret, status string, t time.Time) {
+	if secret.Annotations == nil {
+		secret.Annotations = map[string]string{}
+	}
+	secret.Annotations[oposd.KeyRotationStatusAnnotation] = status
+	secret.Annotations[oposd.KeyRotationTimeAnnotation] = t.UTC().Format(time.RFC3339)
+}
@@ -17,12 +17,17 @@ limitations under the License.
 package osd
 
 import (
+	"io/ioutil"
 	"testing"
 
 	"github.com/pkg/errors"
 	"github.com/rook/rook/pkg/clusterd"
+	oposd "github.com/rook/rook/pkg/operator/ceph/cluster/osd"
 	exectest "github.com/rook/rook/pkg/util/exec/test"
 	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
 )
 
 func TestCloseEncryptedDevice(t *testing.T) {
@@ -40,3 +45,90 @@ func TestCloseEncryptedDevice(t *testing.T) {
 	err := closeEncryptedDevice(context, "/dev/mapper/ceph-43e9efed-0676-4731-b75a-a4c42ece1bb1-xvdbr-block-dmcrypt")
 	assert.NoError(t, err)
 }
+
+// newLUKSExecutor simulates the key slots of luks blocks
+func newLUKSExecutor(t *testing.T, slots map[string]map[string]bool) *exectest.MockExecutor {
+	readKey := func(file string) string {
+		key, err := ioutil.ReadFile(file)
+		assert.NoError(t, err)
+		return string(key)
+	}
+	return &exectest.MockExecutor{
+		MockExecuteCommandWithCombinedOutput: func(command string, args ...string) (string, error) {
+			logger.Infof("%s %v", command, args)
+			switch {
+			case args[0] == "luksOpen" && args[1] == "--test-passphrase":
+				if slots[args[4]][readKey(args[3])] {
+					return "", nil
+				}
+				return "No key available with this passphrase.", errors.New("exit status 2")
+			case args[1] == "luksAddKey":
+				if !slots[args[4]][readKey(args[3])] {
+					return "", errors.New("exit status 2")
+				}
+				slots[args[4]][readKey(args[5])] = true
+				return "", nil
+			case args[1] == "luksRemoveKey":
+				delete(slots[args[2]], readKey(args[3]))
+				return "", nil
+			}
+			return "", errors.Errorf("unknown command %s %s", command, args)
+		},
+	}
+}
+
+func TestRotateEncryptionKey(t *testing.T) {
+	secret := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "osd-key", Namespace: "ns"},
+		Data:       map[string][]byte{"dmcrypt-key": []byte("key1")},
+	}
+	clientset := fake.NewSimpleClientset(secret)
+	slots := map[string]map[string]bool{
+		"/data":     {"key1": true},
+		"/metadata": {"key1": true},
+	}
+	context := &clusterd.Context{Clientset: clientset, Executor: newLUKSExecutor(t, slots)}
+	blocks := []string{"/data", "/metadata"}
+
+	err := RotateEncryptionKey(context, "ns", "osd-key", "dmcrypt-key", blocks)
+	assert.NoError(t, err)
+	secret, err = clientset.CoreV1().Secrets("ns").Get("osd-key", metav1.GetOptions{})
+	assert.NoError(t, err)
+	newKey := string(secret.Data["dmcrypt-key"])
+	assert.NotEqual(t, "key1", newKey)
+	assert.Equal(t, 1, len(secret.Data))
+	assert.Equal(t, oposd.KeyRotationSucceeded, secret.Annotations[oposd.KeyRotationStatusAnnotation])
+	assert.NotEmpty(t, secret.Annotations[oposd.KeyRotationTimeAnnotation])
+	for _, block := range blocks {
+		assert.Equal(t, map[string]bool{newKey: true}, slots[block])
+	}
+
+	// a rotation interrupted after the new key was added to the first block is resumed with the same key
+	secret.Data[pendingEncryptionKeyName] = []byte("key3")
+	_, err = clientset.CoreV1().Secrets("ns").Update(secret)
+	assert.NoError(t, err)
+	slots["/data"]["key3"] = true
+	err = RotateEncryptionKey(context, "ns", "osd-key", "dmcrypt-key", blocks)
+	assert.NoError(t, err)
+	secret, err = clientset.CoreV1().Secrets("ns").Get("osd-key", metav1.GetOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string][]byte{"dmcrypt-key": []byte("key3")}, secret.Data)
+	for _, block := range blocks {
+		assert.Equal(t, map[string]bool{"key3": true}, slots[block])
+	}
+
+	// the previous key is not removed if the key of the secret does not open the block
+	slots["/metadata"] = map[string]bool{"key3": true}
+	slots["/data"] = map[string]bool{"key3": true}
+	secret.Data[previousEncryptionKeyName] = []byte("key3")
+	secret.Data["dmcrypt-key"] = []byte("key4")
+	_, err = clientset.CoreV1().Secrets("ns").Update(secret)
+	assert.NoError(t, err)
+	err = RotateEncryptionKey(context, "ns", "osd-key", "dmcrypt-key", blocks)
+	assert.Error(t, err)
+	secret, err = clientset.CoreV1().Secrets("ns").Get("osd-key", metav1.GetOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, oposd.KeyRotationFailed, secret.Annotations[oposd.KeyRotationStatusAnnotation])
+	assert.Equal(t, []byte("key3"), secret.Data[previousEncryptionKeyName])
+	assert.True(t, slots["/data"]["key3"])
+}
@@ -43,6 +43,7 @@ const (
 	encryptedFlag        = "--dmcrypt"
 	databaseSizeFlag     = "--block-db-size"
 	dbDeviceFlag         = "--db-devices"
+	walDeviceFlag        = "--wal-devices"
 	cephVolumeCmd        = "ceph-volume"
 	cephVolumeMinDBSize  = 1024 // 1GB
 )
@@ -68,9 +69,10 @@ type osdInfoBlock struct {
 }
 
 type osdInfo struct {
-	Name string  `json:"name"`
-	Path string  `json:"path"`
-	Tags osdTags `json:"tags"`
+	Name    string   `json:"name"`
+	Path    string   `json:"path"`
+	Devices []string `json:"devices"`
+	Tags    osdTags  `json:"tags"`
 	// "data" or "journal" for filestore and "block" for bluestore
 	Type string `json:"type"`
 }
@@ -108,6 +110,18 @@ func (a *OsdAgent) configureCVDevices(context *clusterd.Context, devices *Device
 			// See: https://github.com/rook/rook/commit/8ea693a74011c587970dfc28a3d9efe2ef329159
 			skipLVRelease := true
 
+			// List the osds carved out of the PVC with ceph-volume lvm batch
+			if a.storeConfig.OSDsPerDevice > 1 {
+				if err := UpdateLVMConfig(context, a.pvcBacked, lvBackedPV); err != nil {
+					return nil, errors.Wrap(err, "failed to update lvm configuration file")
+				}
+				lvmOsds, err = GetCephVolumeLVMOSDs(context, a.clusterInfo, a.clusterInfo.FSID, "", skipLVRelease, lvBackedPV)
+				if err != nil {
+					logger.Infof("failed to get devices already provisioned by ceph-volume lvm batch. %v", err)
+				}
+				return lvmOsds, nil
+			}
+
 			// For LV mode
 			lvPath = getDeviceLVPath(context, fmt.Sprintf("/mnt/%s", a.nodeName))
 
@@ -170,18 +184,34 @@ func (a *OsdAgent) configureCVDevices(context *clusterd.Context, devices *Device
 		}
 	}
 
+	// Several osds are carved out of the PVC with ceph-volume lvm batch, which doesn't apply to a PV backed by an LV
+	multiOSDPVC := a.pvcBacked && a.storeConfig.OSDsPerDevice > 1 && !lvBackedPV
+	if a.pvcBacked && a.storeConfig.OSDsPerDevice > 1 && lvBackedPV {
+		logger.Warningf("osdsPerDevice is not supported on a PV backed by an LV, a single osd is created")
+	}
+
 	// Update LVM configuration file
 	// Only do this after Ceph Nautilus 14.2.6 since it will use the ceph-volume raw mode by default and not LVM anymore
 	//
-	// Or keep doing this if the PV is backend by an LV already
-	if !a.clusterInfo.CephVersion.IsAtLeast(cephVolumeRawModeMinCephVersion) || lvBackedPV {
+	// Or keep doing this if the PV is backend by an LV already or if several osds are carved out of the PVC
+	if !a.clusterInfo.CephVersion.IsAtLeast(cephVolumeRawModeMinCephVersion) || lvBackedPV || multiOSDPVC {
 		if err := UpdateLVMConfig(context, a.pvcBacked, lvBackedPV); err != nil {
 			return nil, errors.Wrap(err, "failed to update lvm configuration file")
 		}
 	}
 
 	// If running on OSD on PVC
-	if a.pvcBacked {
+	if multiOSDPVC {
+		if err = a.initializeMultiOSDPVC(context, devices); err != nil {
+			return nil, errors.Wrap(err, "failed to initialize osds on PVC")
+		}
+		// all the osds of the PVC are listed
+		lvmOsds, err = GetCephVolumeLVMOSDs(context, a.clusterInfo, a.clusterInfo.FSID, "", false, lvBackedPV)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to get devices already provisioned by ceph-volume lvm batch")
+		}
+		return lvmOsds, nil
+	} else if a.pvcBacked {
 		if block, metadataBlock, walBlock, err = a.initializeBlockPVC(context, devices, lvBackedPV); err != nil {
 			return nil, errors.Wrap(err, "failed to initialize devices on PVC")
 		}
@@ -368,6 +398,41 @@ func (a *OsdAgent) initializeBlockPVC(context *clusterd.Context, devices *Device
 	return blockPath, metadataBlockPath, walBlockPath, nil
 }
 
+// initializeMultiOSDPVC carves osdsPerDevice osds out of the data PVC with ceph-volume lvm batch.
+// The metadata and wal PVCs are not supported.
+func (a *OsdAgent) initializeMultiOSDPVC(context *clusterd.Context, devices *DeviceOsdMapping) error {
+	for name, device := range devices.Entries {
+		if name != pvcDataTypeDevice {
+			return errors.Errorf("osdsPerDevice is not supported with a %s PVC", name)
+		}
+		if device.Data != -1 {
+			logger.Infof("skipping device %q with osd %d already configured", device.Config.Name, device.Data)
+			continue
+		}
+
+		deviceArg := device.Config.Name
+		for _, devlink := range device.PersistentDevicePaths {
+			if strings.HasPrefix(devlink, "/dev/mapper") {
+				deviceArg = devlink
+			}
+		}
+
+		args := []string{"-oL", cephVolumeCmd, "lvm", "batch", "--prepare", "--bluestore", "--yes", osdsPerDeviceFlag, strconv.Itoa(a.storeConfig.OSDsPerDevice)}
+		if crushDeviceClass := os.Getenv(oposd.CrushDeviceClassVarName); crushDeviceClass != "" {
+			args = append(args, crushDeviceClassFlag, crushDeviceClass)
+		}
+		args = append(args, deviceArg)
+
+		logger.Infof("carving %d osds out of device %q", a.storeConfig.OSDsPerDevice, deviceArg)
+		op, err := context.Executor.ExecuteCommandWithCombinedOutput("stdbuf", args...)
+		if err != nil {
+			return errors.Wrapf(err, "failed to run ceph-volume lvm batch. %s", op)
+		}
+		logger.Infof("%v", op)
+	}
+	return nil
+}
+
 func getLVPath(op string) string {
 	tmp := sys.Grep(op, "Volume group")
 	vgtmp := strings.Split(tmp, "\"")
@@ -454,6 +519,8 @@ func (a *OsdAgent) initializeDevices(context *clusterd.Context, devices *DeviceO
 	batchArgs := baseArgs
 
 	metadataDevices := make(map[string]map[string]string)
+	// the metadata and wal volumes already used by an osd prepared on an lv
+	usedVolumes := make(map[string]string)
 	for name, device := range devices.Entries {
 		if device.Data == -1 {
 			if device.Metadata != nil {
@@ -475,6 +542,24 @@ func (a *OsdAgent) initializeDevices(context *clusterd.Context, devices *DeviceO
 				deviceOSDCount = sanitizeOSDsPerDevice(device.Config.OSDsPerDevice)
 			}
 
+			// the wal is only supported along with a metadata device on which the db is created
+			wal := a.walDevice
+			if device.Config.WalDevice != "" {
+				wal = device.Config.WalDevice
+			}
+			if wal != "" && a.metadataDevice == "" && device.Config.MetadataDevice == "" {
+				return errors.Errorf("walDevice (%s) of device %s requires a metadataDevice", wal, deviceArg)
+			}
+
+			// ceph-volume lvm batch doesn't consume lvs, the osds on the lvs listed by path are
+			// prepared one at a time. Partitions are still prepared by the batch below.
+			if device.DeviceInfo != nil && device.DeviceInfo.Type == sys.LVMType && isDesiredByPath(device.DeviceInfo, a.devices) {
+				if err := a.initializeVolume(context, device, wal, usedVolumes); err != nil {
+					return errors.Wrapf(err, "failed to prepare osd on %s", device.DeviceInfo.RealPath)
+				}
+				continue
+			}
+
 			if a.metadataDevice != "" || device.Config.MetadataDevice != "" {
 				// When mixed hdd/ssd devices are given, ceph-volume configures db lv on the ssd.
 				// the device will be configured as a batch at the end of the method
@@ -489,12 +574,20 @@ func (a *OsdAgent) initializeDevices(context *clusterd.Context, devices *DeviceO
 					if deviceOSDCount != metadataDevices[md]["osdsperdevice"] {
 						return errors.Errorf("metadataDevice (%s) has more than 1 osdsPerDevice value set: %s != %s", md, deviceOSDCount, metadataDevices[md]["osdsperdevice"])
 					}
+					// Fail when two devices using the same metadata device have different wal devices
+					if wal != metadataDevices[md]["waldevice"] {
+						return errors.Errorf("metadataDevice (%s) has more than 1 walDevice value set: %q != %q", md, wal, metadataDevices[md]["waldevice"])
+					}
 				} else {
 					metadataDevices[md] = make(map[string]string)
 					metadataDevices[md]["osdsperdevice"] = deviceOSDCount
 					if device.Config.DeviceClass != "" {
 						metadataDevices[md]["deviceclass"] = device.Config.DeviceClass
 					}
+					if wal != "" {
+						logger.Infof("using %s as walDevice for the osds with metadataDevice %s", wal, md)
+						metadataDevices[md]["waldevice"] = wal
+					}
 					metadataDevices[md]["devices"] = deviceArg
 				}
 				deviceDBSizeMB := getDatabaseSize(a.storeConfig.DatabaseSizeMB, device.Config.DatabaseSizeMB)
@@ -577,6 +670,12 @@ func (a *OsdAgent) initializeDevices(context *clusterd.Context, devices *DeviceO
 			dbDeviceFlag,
 			path.Join("/dev", md),
 		}...)
+		if wal, ok := conf["waldevice"]; ok {
+			mdArgs = append(mdArgs, []string{
+				walDeviceFlag,
+				path.Join("/dev", wal),
+			}...)
+		}
 
 		// Reporting
 		reportArgs := append(mdArgs, []string{
@@ -617,6 +716,109 @@ func (a *OsdAgent) initializeDevices(context *clusterd.Context, devices *DeviceO
 	return nil
 }
 
+// initializeVolume prepares an osd on an lv with 'ceph-volume lvm prepare'. The metadata and wal
+// devices of the osd must be lvs or partitions, which are entirely used by this osd. An lv with an
+// invalid configuration is skipped so the other devices of the node are still prepared.
+func (a *OsdAgent) initializeVolume(context *clusterd.Context, device *DeviceOsdIDEntry, wal string, usedVolumes map[string]string) error {
+	args, err := a.getVolumePrepareArgs(context, device, wal, usedVolumes)
+	if err != nil {
+		logger.Errorf("skipping lv %s. %v", device.DeviceInfo.RealPath, err)
+		return nil
+	}
+
+	logger.Infof("preparing osd on lv %s. %+v", device.DeviceInfo.RealPath, args)
+	if err := context.Executor.ExecuteCommand("stdbuf", args...); err != nil {
+		return errors.Wrap(err, "failed ceph-volume")
+	}
+	return nil
+}
+
+// getVolumePrepareArgs returns the 'ceph-volume lvm prepare' arguments of the osd on the given lv
+func (a *OsdAgent) getVolumePrepareArgs(context *clusterd.Context, device *DeviceOsdIDEntry, wal string, usedVolumes map[string]string) ([]string, error) {
+	data, err := getVolumeArg(context, device.DeviceInfo.RealPath, device.DeviceInfo.Type)
+	if err != nil {
+		return nil, err
+	}
+	if device.Config.OSDsPerDevice > 1 || a.storeConfig.OSDsPerDevice > 1 {
+		logger.Warningf("osdsPerDevice is not supported on lv %s, a single osd is created", data)
+	}
+
+	args := []string{"-oL", cephVolumeCmd, "lvm", "prepare", "--bluestore", "--data", data}
+	if a.storeConfig.EncryptedDevice {
+		args = append(args, encryptedFlag)
+	}
+
+	md := a.metadataDevice
+	if device.Config.MetadataDevice != "" {
+		md = device.Config.MetadataDevice
+	}
+	if md != "" {
+		mdArg, err := getMetadataVolumeArg(context, md, data, usedVolumes)
+		if err != nil {
+			return nil, errors.Wrap(err, "invalid metadataDevice")
+		}
+		if getDatabaseSize(a.storeConfig.DatabaseSizeMB, device.Config.DatabaseSizeMB) > 0 {
+			logger.Infof("skipping databaseSizeMB setting for osd on %s, the whole metadata device %s is used", data, mdArg)
+		}
+		args = append(args, "--block.db", mdArg)
+	}
+	if wal != "" {
+		walArg, err := getMetadataVolumeArg(context, wal, data, usedVolumes)
+		if err != nil {
+			return nil, errors.Wrap(err, "invalid walDevice")
+		}
+		args = append(args, "--block.wal", walArg)
+	}
+	if device.Config.DeviceClass != "" {
+		args = append(args, crushDeviceClassFlag, device.Config.DeviceClass)
+	}
+	return args, nil
+}
+
+// getMetadataVolumeArg returns the ceph-volume argument of the lv or partition holding the metadata
+// or the wal of the osd on the given data lv. The volume must be available and can't be shared
+// with another osd.
+func getMetadataVolumeArg(context *clusterd.Context, name, data string, usedVolumes map[string]string) (string, error) {
+	devicePath := name
+	if !strings.HasPrefix(devicePath, "/dev/") {
+		devicePath = path.Join("/dev", name)
+	}
+	if user, ok := usedVolumes[devicePath]; ok {
+		return "", errors.Errorf("%s is already used by the osd on %s, an lv or a partition can only hold the metadata of a single osd", devicePath, user)
+	}
+
+	props, err := sys.GetDevicePropertiesFromPath(devicePath, context.Executor)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to get the properties of %s", devicePath)
+	}
+	arg, err := getVolumeArg(context, devicePath, props["TYPE"])
+	if err != nil {
+		return "", errors.Wrapf(err, "the metadata of the osd on lv or partition %s must be on an lv or a partition", data)
+	}
+	isAvailable, rejectedReason, err := sys.CheckIfDeviceAvailable(context.Executor, devicePath, true)
+	if err != nil {
+		return "", err
+	}
+	if !isAvailable {
+		return "", errors.Errorf("%s is not available: %s", devicePath, rejectedReason)
+	}
+
+	usedVolumes[devicePath] = data
+	return arg, nil
+}
+
+// getVolumeArg returns how ceph-volume designates the lv or partition at the given path, "vg/lv"
+// for an lv and the path for a partition
+func getVolumeArg(context *clusterd.Context, devicePath, deviceType string) (string, error) {
+	switch deviceType {
+	case sys.LVMType:
+		return sys.GetLVName(context.Executor, devicePath)
+	case sys.PartType:
+		return devicePath, nil
+	}
+	return "", errors.Errorf("%s is neither an lv nor a partition but a %q device", devicePath, deviceType)
+}
+
 func getDatabaseSize(globalSize int, deviceSize int) int {
 	if deviceSize > 0 {
 		globalSize = deviceSize
@@ -631,6 +833,25 @@ func sanitizeOSDsPerDevice(count int) string {
 	return strconv.Itoa(count)
 }
 
+// getDeviceID returns the identity of the device, its WWN or its serial. Unlike the device name,
+// the identity changes when the device is physically replaced.
+func getDeviceID(context *clusterd.Context, devicePath string) string {
+	if devicePath == "" {
+		return ""
+	}
+	udevInfo, err := sys.GetUdevInfo(strings.TrimPrefix(devicePath, "/dev/"), context.Executor)
+	if err != nil {
+		logger.Warningf("failed to get the udev info of device %q. %v", devicePath, err)
+		return ""
+	}
+	for _, key := range []string{"ID_WWN_WITH_EXTENSION", "ID_WWN", "ID_SERIAL"} {
+		if id, ok := udevInfo[key]; ok && id != "" {
+			return id
+		}
+	}
+	return ""
+}
+
 // GetCephVolumeLVMOSDs list OSD prepared with lvm mode
 func GetCephVolumeLVMOSDs(context *clusterd.Context, clusterInfo *client.ClusterInfo, cephfsid, lv string, skipLVRelease, lvBackedPV bool) ([]oposd.OSDInfo, error) {
 	// lv can be a block device if raw mode is used
@@ -656,7 +877,7 @@ func GetCephVolumeLVMOSDs(context *clusterd.Context, clusterInfo *client.Cluster
 			logger.Errorf("bad osd returned from ceph-volume %q", name)
 			continue
 		}
-		var osdFSID string
+		var osdFSID, devicePath string
 		store := "bluestore"
 		for _, osd := range osdInfo {
 			if osd.Tags.ClusterFSID != cephfsid {
@@ -667,6 +888,9 @@ func GetCephVolumeLVMOSDs(context *clusterd.Context, clusterInfo *client.Cluster
 			if osd.Type == "journal" {
 				store = "filestore"
 			}
+			if (osd.Type == "block" || osd.Type == "data") && len(osd.Devices) > 0 {
+				devicePath = osd.Devices[0]
+			}
 
 			// If no lv is specified let's take the one we discovered
 			if lv == "" {
@@ -695,6 +919,8 @@ func GetCephVolumeLVMOSDs(context *clusterd.Context, clusterInfo *client.Cluster
 			LVBackedPV:    lvBackedPV,
 			CVMode:        cvMode,
 			Store:         store,
+			DevicePath:    devicePath,
+			DeviceID:      getDeviceID(context, devicePath),
 		}
 		osds = append(osds, osd)
 	}
@@ -20,15 +20,18 @@ import (
 	"io/ioutil"
 	"os"
 	"path"
+	"strings"
 	"testing"
 
 	"github.com/pkg/errors"
 	"github.com/rook/rook/pkg/clusterd"
 	cephclient "github.com/rook/rook/pkg/daemon/ceph/client"
 	oposd "github.com/rook/rook/pkg/operator/ceph/cluster/osd"
+	"github.com/rook/rook/pkg/operator/ceph/cluster/osd/config"
 	cephver "github.com/rook/rook/pkg/operator/ceph/version"
 	"github.com/rook/rook/pkg/operator/test"
 	exectest "github.com/rook/rook/pkg/util/exec/test"
+	"github.com/rook/rook/pkg/util/sys"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -395,6 +398,42 @@ func TestInitializeBlockPVC(t *testing.T) {
 	assert.Equal(t, "", walBlockPath)
 }
 
+func TestInitializeMultiOSDPVC(t *testing.T) {
+	var batchArgs []string
+	executor := &exectest.MockExecutor{}
+	executor.MockExecuteCommandWithCombinedOutput = func(command string, args ...string) (string, error) {
+		logger.Infof("%s %v", command, args)
+		if args[1] == "ceph-volume" && args[2] == "lvm" && args[3] == "batch" {
+			batchArgs = args
+			return "", nil
+		}
+		return "", errors.Errorf("unknown command %s %s", command, args)
+	}
+	context := &clusterd.Context{Executor: executor}
+	a := &OsdAgent{nodeName: "node1", pvcBacked: true, storeConfig: config.StoreConfig{OSDsPerDevice: 4}}
+	devices := &DeviceOsdMapping{
+		Entries: map[string]*DeviceOsdIDEntry{
+			"data": {Data: -1, Config: DesiredDevice{Name: "/mnt/set1-data-0-rpf2k"}},
+		},
+	}
+
+	err := a.initializeMultiOSDPVC(context, devices)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"-oL", "ceph-volume", "lvm", "batch", "--prepare", "--bluestore", "--yes", "--osds-per-device", "4", "/mnt/set1-data-0-rpf2k"}, batchArgs)
+
+	// the osds are already carved out of the pvc
+	batchArgs = nil
+	devices.Entries["data"].Data = 0
+	err = a.initializeMultiOSDPVC(context, devices)
+	assert.NoError(t, err)
+	assert.Nil(t, batchArgs)
+
+	// the metadata pvcs are not supported
+	devices.Entries["metadata"] = &DeviceOsdIDEntry{Data: -1, Config: DesiredDevice{Name: "/mnt/set1-metadata-0-rpf2k"}}
+	err = a.initializeMultiOSDPVC(context, devices)
+	assert.Error(t, err)
+}
+
 func TestInitializeBlockPVCWithMetadata(t *testing.T) {
 	executor := &exectest.MockExecutor{}
 	executor.MockExecuteCommandWithCombinedOutput = func(command string, args ...string) (string, error) {
@@ -506,6 +545,27 @@ func TestParseCephVolumeLVMResult(t *testing.T) {
 	assert.Nil(t, err)
 	require.NotNil(t, osds)
 	assert.Equal(t, 2, len(osds))
+	assert.ElementsMatch(t, []string{"/dev/sdb", "/dev/sdc"}, []string{osds[0].DevicePath, osds[1].DevicePath})
+}
+
+func TestGetDeviceID(t *testing.T) {
+	udevOutput := "DEVNAME=/dev/sdb\nID_SERIAL=ST4000NM0033_Z1Z5P2K5\n"
+	executor := &exectest.MockExecutor{}
+	executor.MockExecuteCommandWithOutput = func(command string, args ...string) (string, error) {
+		if command == "udevadm" && args[2] == "/dev/sdb" {
+			return udevOutput, nil
+		}
+		return "", errors.Errorf("unknown command %s %s", command, args)
+	}
+	context := &clusterd.Context{Executor: executor}
+
+	assert.Equal(t, "", getDeviceID(context, ""))
+	assert.Equal(t, "", getDeviceID(context, "/dev/sdc"))
+	assert.Equal(t, "ST4000NM0033_Z1Z5P2K5", getDeviceID(context, "/dev/sdb"))
+
+	// the wwn is preferred over the serial
+	udevOutput += "ID_WWN=0x5000c500a0a0a0a0\n"
+	assert.Equal(t, "0x5000c500a0a0a0a0", getDeviceID(context, "/dev/sdb"))
 }
 
 func TestParseCephVolumeRawResult(t *testing.T) {
@@ -588,6 +648,133 @@ func TestGetDatabaseSize(t *testing.T) {
 	assert.Equal(t, 2048, getDatabaseSize(4096, 2048))
 }
 
+func TestInitializeDevicesWithWalDevice(t *testing.T) {
+	var batchArgs []string
+	executor := &exectest.MockExecutor{}
+	executor.MockExecuteCommand = func(command string, args ...string) error {
+		logger.Infof("%s %v", command, args)
+		batchArgs = args
+		return nil
+	}
+	executor.MockExecuteCommandWithCombinedOutput = func(command string, args ...string) (string, error) {
+		return `{"changed": true, "vg": {"devices": "/dev/sdc"}}`, nil
+	}
+	context := &clusterd.Context{Executor: executor}
+
+	devices := &DeviceOsdMapping{
+		Entries: map[string]*DeviceOsdIDEntry{
+			"sda": {Data: -1, Config: DesiredDevice{Name: "sda", MetadataDevice: "sdc", WalDevice: "nvme0n1"}},
+			"sdb": {Data: -1, Config: DesiredDevice{Name: "sdb", MetadataDevice: "sdc", WalDevice: "nvme0n1"}},
+		},
+	}
+	a := &OsdAgent{storeConfig: config.StoreConfig{OSDsPerDevice: 1}}
+	err := a.initializeDevices(context, devices)
+	assert.NoError(t, err)
+	assert.Contains(t, batchArgs, "--db-devices")
+	assert.Contains(t, batchArgs, "/dev/sdc")
+	assert.Contains(t, batchArgs, "--wal-devices")
+	assert.Contains(t, batchArgs, "/dev/nvme0n1")
+
+	// the devices sharing a metadata device must share the wal device
+	devices.Entries["sdb"].Config.WalDevice = "nvme1n1"
+	err = a.initializeDevices(context, devices)
+	assert.Error(t, err)
+
+	// the wal device requires a metadata device
+	devices = &DeviceOsdMapping{
+		Entries: map[string]*DeviceOsdIDEntry{
+			"sda": {Data: -1, Config: DesiredDevice{Name: "sda", WalDevice: "nvme0n1"}},
+		},
+	}
+	err = a.initializeDevices(context, devices)
+	assert.Error(t, err)
+}
+
+func TestInitializeDevicesOnVolumes(t *testing.T) {
+	prepared := map[string][]string{}
+	batched := [][]string{}
+	executor := &exectest.MockExecutor{}
+	executor.MockExecuteCommand = func(command string, args ...string) error {
+		logger.Infof("%s %v", command, args)
+		for i, arg := range args {
+			if arg == "--data" {
+				prepared[args[i+1]] = args
+			}
+			if arg == "batch" && !strings.Contains(strings.Join(args, " "), "--report") {
+				batched = append(batched, args)
+			}
+		}
+		return nil
+	}
+	executor.MockExecuteCommandWithOutput = func(command string, args ...string) (string, error) {
+		switch {
+		case command == "lsblk" && (args[0] == "/dev/vg1/db1" || args[0] == "/dev/vg1/db2"):
+			return `TYPE="lvm"`, nil
+		case command == "lsblk" && args[0] == "/dev/nvme0n1p1":
+			return `TYPE="part"`, nil
+		case command == "lsblk":
+			return `TYPE="disk"`, nil
+		case command == "dmsetup" && args[0] == "info":
+			return "vg1-" + strings.TrimPrefix(path.Base(args[5]), "vg1-"), nil
+		case command == "dmsetup" && args[0] == "splitname":
+			return strings.Replace(args[2], "-", ":", 1) + ":", nil
+		case command == "ceph-volume" && args[0] == "inventory":
+			return cvInventoryOutputAvailable, nil
+		case command == "ceph-volume" && args[0] == "lvm":
+			return "{}", nil
+		}
+		return "", errors.Errorf("unknown command %s %s", command, args)
+	}
+	context := &clusterd.Context{Executor: executor}
+
+	lv1 := &sys.LocalDisk{Name: "dm-0", RealPath: "/dev/mapper/vg1-lv1", DevLinks: "/dev/vg1/lv1 /dev/mapper/vg1-lv1", Type: sys.LVMType}
+	lv2 := &sys.LocalDisk{Name: "dm-1", RealPath: "/dev/mapper/vg1-lv2", DevLinks: "/dev/vg1/lv2 /dev/mapper/vg1-lv2", Type: sys.LVMType}
+	part := &sys.LocalDisk{Name: "sdt1", RealPath: "/dev/sdt1", Type: sys.PartType}
+	devices := &DeviceOsdMapping{
+		Entries: map[string]*DeviceOsdIDEntry{
+			"dm-0": {Data: -1, Config: DesiredDevice{Name: "/dev/vg1/lv1", MetadataDevice: "/dev/vg1/db1", DeviceClass: "ssd"}, DeviceInfo: lv1},
+			"sdt1": {Data: -1, Config: DesiredDevice{Name: "sdt1", OSDsPerDevice: 2}, DeviceInfo: part},
+		},
+	}
+	a := &OsdAgent{
+		devices:     []DesiredDevice{{Name: "/dev/vg1/lv1"}, {Name: "/dev/vg1/lv2"}, {Name: "sdt1"}},
+		storeConfig: config.StoreConfig{OSDsPerDevice: 1},
+	}
+	err := a.initializeDevices(context, devices)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(prepared))
+	assert.Contains(t, prepared["vg1/lv1"], "prepare")
+	assert.Contains(t, prepared["vg1/lv1"], "--block.db")
+	assert.Contains(t, prepared["vg1/lv1"], "vg1/db1")
+	assert.Contains(t, prepared["vg1/lv1"], "ssd")
+	// the partitions are still prepared by the batch with their osdsPerDevice
+	require.Equal(t, 1, len(batched))
+	assert.Contains(t, batched[0], "/dev/sdt1")
+	assert.Contains(t, batched[0], "2")
+
+	// an lv or a partition holds the metadata of a single osd, the invalid lv is skipped
+	prepared = map[string][]string{}
+	batched = [][]string{}
+	devices.Entries["dm-1"] = &DeviceOsdIDEntry{Data: -1, Config: DesiredDevice{Name: "/dev/vg1/lv2", MetadataDevice: "/dev/vg1/db1"}, DeviceInfo: lv2}
+	err = a.initializeDevices(context, devices)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(prepared))
+	assert.Equal(t, 1, len(batched))
+
+	// the metadata of an osd on an lv can't be on a whole device
+	prepared = map[string][]string{}
+	devices = &DeviceOsdMapping{
+		Entries: map[string]*DeviceOsdIDEntry{
+			"dm-0": {Data: -1, Config: DesiredDevice{Name: "/dev/vg1/lv1", MetadataDevice: "nvme0n1"}, DeviceInfo: lv1},
+			"dm-1": {Data: -1, Config: DesiredDevice{Name: "/dev/vg1/lv2", MetadataDevice: "/dev/vg1/db2"}, DeviceInfo: lv2},
+		},
+	}
+	err = a.initializeDevices(context, devices)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(prepared))
+	assert.Contains(t, prepared["vg1/lv2"], "vg1/db2")
+}
+
 func TestPrintCVLogContent(t *testing.T) {
 	tmp, err := ioutil.TempFile("", "cv-log")
 	assert.Nil(t, err)
@@ -286,6 +286,10 @@ func checkDeviceListsEqual(oldDevs, newDevs []sys.LocalDisk) bool {
 			// return ceph volume inventory data was not enabled before
 			return false
 		}
+		if (oldDev.Health == nil || oldDev.Health.Passed) && match.Health != nil && !match.Health.Passed {
+			// device started failing
+			return false
+		}
 	}
 
 	for _, newDev := range newDevs {
@@ -448,6 +452,13 @@ func probeDevices(context *clusterd.Context) ([]sys.LocalDisk, error) {
 		device.Filesystem = fs
 		device.Empty = clusterd.GetDeviceEmpty(device)
 
+		// the health is informative, the devices without smart data are still reported
+		health, err := sys.GetDeviceHealth(device.RealPath, context.Executor)
+		if err != nil {
+			logger.Debugf("failed to get the health of device %q. %v", device.Name, err)
+		}
+		device.Health = health
+
 		// Add the information provided by ceph-volume inventory
 		if cvInventory != nil {
 			CVData, deviceExists := (*cvInventory)[path.Join("/dev/", device.Name)]
@@ -335,6 +335,38 @@ func TestDeviceListsEqual(t *testing.T) {
 			},
 		},
 	))
+
+	// the device started failing
+	assert.False(t, checkDeviceListsEqual(
+		[]sys.LocalDisk{
+			{
+				UUID:   "uuid",
+				Health: &sys.DeviceHealth{Passed: true},
+			},
+		},
+		[]sys.LocalDisk{
+			{
+				UUID:   "uuid",
+				Health: &sys.DeviceHealth{Passed: false},
+			},
+		},
+	))
+
+	// the wearout of the device increased, not of interest
+	assert.True(t, checkDeviceListsEqual(
+		[]sys.LocalDisk{
+			{
+				UUID:   "uuid",
+				Health: &sys.DeviceHealth{Passed: true, MediaWearout: 10},
+			},
+		},
+		[]sys.LocalDisk{
+			{
+				UUID:   "uuid",
+				Health: &sys.DeviceHealth{Passed: true, MediaWearout: 11},
+			},
+		},
+	))
 }
 
 func TestGetCephVolumeInventory(t *testing.T) {
@@ -18,6 +18,7 @@ limitations under the License.
 package cluster
 
 import (
+	"context"
 	"fmt"
 	"path"
 	"strings"
@@ -253,6 +254,13 @@ func (c *ClusterController) configureLocalCephCluster(cluster *cluster, clusterO
 	// Set the value of isUpgrade based on the image discovery done by detectAndValidateCephVersion()
 	cluster.isUpgrade = isUpgrade
 
+	// Restore the mon quorum if requested, before the mons are orchestrated
+	if healthyMon, ok := clusterObj.Annotations[mon.RestoreQuorumAnnotation]; ok {
+		if err := c.restoreMonQuorum(cluster, *cephVersion, healthyMon); err != nil {
+			return errors.Wrap(err, "failed to restore the mon quorum")
+		}
+	}
+
 	// Set the condition to the cluster object
 	message := config.CheckConditionReady(c.context, c.namespacedName)
 	config.ConditionExport(c.context, c.namespacedName, cephv1.ConditionProgressing, v1.ConditionTrue, "ClusterProgressing", message)
@@ -270,6 +278,30 @@ func (c *ClusterController) configureLocalCephCluster(cluster *cluster, clusterO
 	return nil
 }
 
+// restoreMonQuorum restores the mon quorum from the given healthy mon. The restore annotation is
+// removed whatever the outcome so that the restore is never run again by accident. It must be
+// set again to retry a failed restore.
+func (c *ClusterController) restoreMonQuorum(cluster *cluster, cephVersion cephver.CephVersion, healthyMon string) error {
+	logger.Warningf("restoring the mon quorum of cluster %q from mon %q as requested by the %q annotation", cluster.Namespace, healthyMon, mon.RestoreQuorumAnnotation)
+	config.ConditionExport(c.context, c.namespacedName, cephv1.ConditionProgressing, v1.ConditionTrue, "MonQuorumRestoring", fmt.Sprintf("Restoring the mon quorum from mon %q", healthyMon))
+	restoreErr := cluster.mons.RestoreQuorum(cephVersion, *cluster.Spec, healthyMon)
+
+	clusterObj := &cephv1.CephCluster{}
+	if err := c.client.Get(context.TODO(), c.namespacedName, clusterObj); err != nil {
+		return errors.Wrap(err, "failed to get the cluster to remove the mon quorum restore annotation")
+	}
+	delete(clusterObj.Annotations, mon.RestoreQuorumAnnotation)
+	if err := c.client.Update(context.TODO(), clusterObj); err != nil {
+		return errors.Wrap(err, "failed to remove the mon quorum restore annotation")
+	}
+
+	if restoreErr != nil {
+		config.ConditionExport(c.context, c.namespacedName, cephv1.ConditionFailure, v1.ConditionTrue, "MonQuorumRestoreFailed", restoreErr.Error())
+		return restoreErr
+	}
+	return nil
+}
+
 func (c *cluster) notifyChildControllerOfUpgrade() error {
 	version := strings.Replace(c.ClusterInfo.CephVersion.String(), " ", "-", -1)
 
@@ -332,8 +364,18 @@ func (c *ClusterController) preClusterStartValidation(cluster *cluster, clusterO
 		logger.Warningf("mon count should be at least 1, will use default value of %d", mon.DefaultMonCount)
 		cluster.Spec.Mon.Count = mon.DefaultMonCount
 	}
-	if cluster.Spec.Mon.Count%2 == 0 {
-		logger.Warningf("mon count is even (given: %d), should be uneven, continuing", cluster.Spec.Mon.Count)
+	// an even mon count is a quorum risk, but it is still allowed. allowEvenCount only silences the
+	// warning, the mon health checker manages an even count like an odd one
+	quorumRisk := mon.MonCountQuorumRisk(cluster.Spec.Mon)
+	if quorumRisk != "" {
+		if !cluster.Spec.Mon.AllowEvenCount {
+			logger.Warningf("%s. set allowEvenCount to true to acknowledge the risk, continuing", quorumRisk)
+		}
+		if !isConditionTrueWithMessage(clusterObj, cephv1.ConditionMonQuorumRisk, quorumRisk) {
+			config.ConditionExport(c.context, c.namespacedName, cephv1.ConditionMonQuorumRisk, v1.ConditionTrue, "EvenMonCount", quorumRisk)
+		}
+	} else if isConditionTrue(clusterObj, cephv1.ConditionMonQuorumRisk) {
+		config.ConditionExport(c.context, c.namespacedName, cephv1.ConditionMonQuorumRisk, v1.ConditionFalse, "OddMonCount", "Mon count is odd")
 	}
 	if len(cluster.Spec.Storage.Directories) != 0 {
 		logger.Warning("running osds on directory is not supported anymore, use devices instead.")
@@ -134,8 +134,9 @@ func newReconciler(mgr manager.Manager, context *clusterd.Context, clusterContro
 }
 
 func add(mgr manager.Manager, r reconcile.Reconciler, context *clusterd.Context) error {
-	// Create a new controller
-	c, err := controller.New(controllerName, mgr, controller.Options{Reconciler: r})
+	// The ceph clusters are always reconciled one at a time since their reconciles share process-wide
+	// state, like the conditions of the cluster
+	c, err := controller.New(controllerName, mgr, opcontroller.SerialControllerOptions(context, controllerName, r))
 	if err != nil {
 		return err
 	}
@@ -230,6 +231,10 @@ func (r *ReconcileCephCluster) Reconcile(request reconcile.Request) (reconcile.R
 		logger.Errorf("failed to reconcile. %v", err)
 	}
 
+	if opcontroller.DryRun {
+		reportDryRunChanges(r.context, request.NamespacedName)
+	}
+
 	return reconcileResponse, err
 }
 
@@ -255,6 +260,16 @@ func (r *ReconcileCephCluster) reconcile(request reconcile.Request) (reconcile.R
 		return reconcile.Result{}, errors.Wrap(err, "failed to get cephCluster")
 	}
 
+	// Do nothing while the reconcile is paused, not even the deletion
+	if opcontroller.IsReconcilePaused(cephCluster) {
+		logger.Infof("reconcile of ceph cluster %q is paused with the %q annotation, skipping", cephCluster.Name, opcontroller.PauseReconcileAnnotation)
+		config.ConditionExport(r.context, request.NamespacedName, cephv1.ConditionPaused, v1.ConditionTrue, "ReconcilePaused", "Cluster reconcile is paused")
+		return reconcile.Result{}, nil
+	}
+	if isConditionTrue(cephCluster, cephv1.ConditionPaused) {
+		config.ConditionExport(r.context, request.NamespacedName, cephv1.ConditionPaused, v1.ConditionFalse, "ReconcileResumed", "Cluster reconcile is resumed")
+	}
+
 	// Set a finalizer so we can do cleanup before the object goes away
 	err = opcontroller.AddFinalizerIfNotPresent(r.client, cephCluster)
 	if err != nil {
@@ -515,6 +530,26 @@ func updateStatus(client client.Client, name types.NamespacedName, status cephv1
 	logger.Debugf("ceph cluster %q status updated to %q", name, status)
 }
 
+// isConditionTrue returns whether the cluster has the given condition with a true status
+func isConditionTrue(cephCluster *cephv1.CephCluster, conditionType cephv1.ConditionType) bool {
+	for _, condition := range cephCluster.Status.Conditions {
+		if condition.Type == conditionType {
+			return condition.Status == v1.ConditionTrue
+		}
+	}
+	return false
+}
+
+// isConditionTrueWithMessage returns whether the condition is already true with the same message
+func isConditionTrueWithMessage(cephCluster *cephv1.CephCluster, conditionType cephv1.ConditionType, message string) bool {
+	for _, condition := range cephCluster.Status.Conditions {
+		if condition.Type == conditionType {
+			return condition.Status == v1.ConditionTrue && condition.Message == message
+		}
+	}
+	return false
+}
+
 // removeFinalizer removes a finalizer
 func removeFinalizer(client client.Client, name types.NamespacedName) error {
 	cephCluster := &cephv1.CephCluster{}
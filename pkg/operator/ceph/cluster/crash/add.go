@@ -22,6 +22,7 @@ import (
 
 	"github.com/pkg/errors"
 	"github.com/rook/rook/pkg/clusterd"
+	opcontroller "github.com/rook/rook/pkg/operator/ceph/controller"
 	"github.com/rook/rook/pkg/operator/k8sutil"
 
 	appsv1 "k8s.io/api/apps/v1"
@@ -46,7 +47,7 @@ const (
 
 // Add adds a new Controller based on nodedrain.ReconcileNode and registers the relevant watches and handlers
 func Add(mgr manager.Manager, context *clusterd.Context) error {
-	return add(mgr, newReconciler(mgr, context))
+	return add(mgr, newReconciler(mgr, context), context)
 }
 
 // newReconciler returns a new reconcile.Reconciler
@@ -57,9 +58,9 @@ func newReconciler(mgr manager.Manager, context *clusterd.Context) reconcile.Rec
 	}
 }
 
-func add(mgr manager.Manager, r reconcile.Reconciler) error {
+func add(mgr manager.Manager, r reconcile.Reconciler, context *clusterd.Context) error {
 	// Create a new controller
-	c, err := controller.New(controllerName, mgr, controller.Options{Reconciler: r})
+	c, err := controller.New(controllerName, mgr, opcontroller.ControllerOptions(context, controllerName, r))
 	if err != nil {
 		return errors.Wrapf(err, "failed to create a new %q", controllerName)
 	}
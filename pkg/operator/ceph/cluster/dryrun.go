@@ -0,0 +1,52 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"fmt"
+	"strings"
+
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/clusterd"
+	"github.com/rook/rook/pkg/operator/ceph/config"
+	"github.com/rook/rook/pkg/operator/k8sutil"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// the maximum number of planned changes listed in the status of the cluster
+const maxDryRunChangesReported = 20
+
+// reportDryRunChanges reports the changes planned in the cluster namespace since the last report
+// in the DryRun condition of the cluster
+func reportDryRunChanges(context *clusterd.Context, namespacedName types.NamespacedName) {
+	changes := k8sutil.DryRunChanges.Flush(namespacedName.Namespace)
+	if len(changes) == 0 {
+		return
+	}
+	config.ConditionExport(context, namespacedName, cephv1.ConditionDryRun, v1.ConditionTrue, "PlannedChanges", dryRunMessage(changes))
+}
+
+// dryRunMessage summarizes the planned changes. The list is partial since a reconcile stops at the
+// first ceph command which is skipped, the changes depending on it are not planned.
+func dryRunMessage(changes []string) string {
+	message := fmt.Sprintf("dry-run mode, %d planned changes not applied (partial list): ", len(changes))
+	if len(changes) > maxDryRunChangesReported {
+		return message + strings.Join(changes[:maxDryRunChangesReported], "; ") + fmt.Sprintf("; and %d more", len(changes)-maxDryRunChangesReported)
+	}
+	return message + strings.Join(changes, "; ")
+}
@@ -0,0 +1,38 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDryRunMessage(t *testing.T) {
+	message := dryRunMessage([]string{"run ceph osd out 1", "delete /api/v1/namespaces/rook-ceph/configmaps/foo"})
+	assert.Equal(t, "dry-run mode, 2 planned changes not applied (partial list): run ceph osd out 1; delete /api/v1/namespaces/rook-ceph/configmaps/foo", message)
+
+	changes := []string{}
+	for i := 0; i < 25; i++ {
+		changes = append(changes, fmt.Sprintf("run ceph osd out %d", i))
+	}
+	message = dryRunMessage(changes)
+	assert.True(t, strings.HasPrefix(message, "dry-run mode, 25 planned changes not applied (partial list): run ceph osd out 0;"))
+	assert.True(t, strings.HasSuffix(message, "run ceph osd out 19; and 5 more"))
+}
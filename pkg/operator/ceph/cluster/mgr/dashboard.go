@@ -36,7 +36,7 @@ const (
 	dashboardModuleName = "dashboard"
 	dashboardPortHTTPS  = 8443
 	dashboardPortHTTP   = 7000
-	dashboardUsername   = "admin"
+	DashboardUsername   = "admin"
 	// #nosec because of the word `Password`
 	dashboardPasswordName          = "rook-ceph-dashboard-password"
 	passwordLength                 = 20
@@ -112,7 +112,13 @@ func (c *Cluster) configureDashboardModules() error {
 	}
 	if hasChanged {
 		logger.Infof("dashboard config has changed. restarting the dashboard module.")
-		return c.restartDashboard()
+		if err := c.restartDashboard(); err != nil {
+			return err
+		}
+	}
+
+	if err := c.configureDashboardSSO(); err != nil {
+		return errors.Wrap(err, "failed to configure dashboard sso")
 	}
 	return nil
 }
@@ -211,11 +217,11 @@ func (c *Cluster) createSelfSignedCert() (bool, error) {
 
 func (c *Cluster) setLoginCredentials(password string) error {
 	// Set the login credentials. Write the command/args to the debug log so we don't write the password by default to the log.
-	logger.Infof("setting ceph dashboard %q login creds", dashboardUsername)
+	logger.Infof("setting ceph dashboard %q login creds", DashboardUsername)
 
 	// retry a few times in the case that the mgr module is not ready to accept commands
 	_, err := client.ExecuteCephCommandWithRetry(func() (string, []byte, error) {
-		args := []string{"dashboard", "set-login-credentials", dashboardUsername, password}
+		args := []string{"dashboard", "set-login-credentials", DashboardUsername, password}
 		cmd := client.NewCephCommand(c.context, c.clusterInfo, args)
 		output, err := cmd.RunWithTimeout(client.CmdExecuteTimeout)
 		return "set dashboard creds", output, err
@@ -0,0 +1,243 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package dashboarduser to manage the users of the Ceph dashboard
+package dashboarduser
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/coreos/pkg/capnslog"
+	"github.com/pkg/errors"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/clusterd"
+	"github.com/rook/rook/pkg/operator/ceph/cluster/mgr"
+	"github.com/rook/rook/pkg/operator/ceph/cluster/mon"
+	opcontroller "github.com/rook/rook/pkg/operator/ceph/controller"
+	"github.com/rook/rook/pkg/operator/k8sutil"
+	"github.com/rook/rook/pkg/util/exec"
+
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+const (
+	controllerName = "ceph-dashboard-user-controller"
+)
+
+// the users are reconciled periodically to set back the roles and info changed in the dashboard
+var userRequeueInterval = 5 * time.Minute
+
+var logger = capnslog.NewPackageLogger("github.com/rook/rook", controllerName)
+
+var cephDashboardUserKind = reflect.TypeOf(cephv1.CephDashboardUser{}).Name()
+
+// Sets the type meta for the controller main object
+var controllerTypeMeta = metav1.TypeMeta{
+	Kind:       cephDashboardUserKind,
+	APIVersion: fmt.Sprintf("%s/%s", cephv1.CustomResourceGroup, cephv1.Version),
+}
+
+// ReconcileCephDashboardUser reconciles a cephDashboardUser object
+type ReconcileCephDashboardUser struct {
+	context  *clusterd.Context
+	client   client.Client
+	scheme   *runtime.Scheme
+	exitCode func(err error) (int, bool)
+}
+
+// Add creates a new cephDashboardUser Controller and adds it to the Manager. The Manager will set fields on the Controller
+// and Start it when the Manager is Started.
+func Add(mgr manager.Manager, context *clusterd.Context) error {
+	return add(mgr, newReconciler(mgr, context), context)
+}
+
+// newReconciler returns a new reconcile.Reconciler
+func newReconciler(mgr manager.Manager, context *clusterd.Context) reconcile.Reconciler {
+	// Add the cephv1 scheme to the manager scheme so that the controller knows about it
+	mgrScheme := mgr.GetScheme()
+	if err := cephv1.AddToScheme(mgr.GetScheme()); err != nil {
+		panic(err)
+	}
+	return &ReconcileCephDashboardUser{
+		client:   mgr.GetClient(),
+		scheme:   mgrScheme,
+		context:  context,
+		exitCode: exec.ExitStatus,
+	}
+}
+
+func add(mgr manager.Manager, r reconcile.Reconciler, context *clusterd.Context) error {
+	// Create a new controller
+	c, err := controller.New(controllerName, mgr, opcontroller.ControllerOptions(context, controllerName, r))
+	if err != nil {
+		return err
+	}
+
+	// Watch for changes on the cephDashboardUser CRD object
+	err = c.Watch(&source.Kind{Type: &cephv1.CephDashboardUser{TypeMeta: controllerTypeMeta}}, &handler.EnqueueRequestForObject{}, opcontroller.WatchControllerPredicate())
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Reconcile reads that state of the cluster for a cephDashboardUser object and makes changes based on the state read
+// and what is in the cephDashboardUser.Spec
+// The Controller will requeue the Request to be processed again if the returned error is non-nil or
+// Result.Requeue is true, otherwise upon completion it will remove the work from the queue.
+func (r *ReconcileCephDashboardUser) Reconcile(request reconcile.Request) (reconcile.Result, error) {
+	// workaround because the rook logging mechanism is not compatible with the controller-runtime loggin interface
+	reconcileResponse, err := r.reconcile(request)
+	if err != nil {
+		logger.Errorf("failed to reconcile %v", err)
+	}
+
+	return reconcileResponse, err
+}
+
+func (r *ReconcileCephDashboardUser) reconcile(request reconcile.Request) (reconcile.Result, error) {
+	// Fetch the cephDashboardUser instance
+	cephDashboardUser := &cephv1.CephDashboardUser{}
+	err := r.client.Get(context.TODO(), request.NamespacedName, cephDashboardUser)
+	if err != nil {
+		if kerrors.IsNotFound(err) {
+			logger.Debug("cephDashboardUser resource not found. Ignoring since object must be deleted.")
+			return reconcile.Result{}, nil
+		}
+		// Error reading the object - requeue the request.
+		return reconcile.Result{}, errors.Wrap(err, "failed to get cephDashboardUser")
+	}
+
+	// Do nothing while the reconcile is paused, not even the deletion
+	if opcontroller.IsReconcilePaused(cephDashboardUser) {
+		logger.Infof("reconcile of CephDashboardUser %q is paused with the %q annotation, skipping", request.NamespacedName, opcontroller.PauseReconcileAnnotation)
+		updateStatus(r.client, request.NamespacedName, k8sutil.PausedStatus, nil)
+		return reconcile.Result{}, nil
+	}
+
+	// The CR was just created, initializing status fields
+	if cephDashboardUser.Status == nil {
+		updateStatus(r.client, request.NamespacedName, k8sutil.Created, nil)
+	}
+
+	// Make sure a CephCluster is present otherwise do nothing
+	_, isReadyToReconcile, cephClusterExists, reconcileResponse := opcontroller.IsReadyToReconcile(r.client, r.context, request.NamespacedName, controllerName)
+	if !isReadyToReconcile {
+		// The users are gone with the cluster, only the finalizer is removed
+		if !cephDashboardUser.GetDeletionTimestamp().IsZero() && !cephClusterExists {
+			err := opcontroller.RemoveFinalizer(r.client, cephDashboardUser)
+			if err != nil {
+				return reconcile.Result{}, errors.Wrap(err, "failed to remove finalizer")
+			}
+			return reconcile.Result{}, nil
+		}
+		logger.Debugf("CephCluster resource not ready in namespace %q, retrying in %q.", request.NamespacedName.Namespace, reconcileResponse.RequeueAfter.String())
+		return reconcileResponse, nil
+	}
+
+	// Populate clusterInfo
+	// Always populate it during each reconcile
+	clusterInfo, _, _, err := mon.LoadClusterInfo(r.context, request.NamespacedName.Namespace)
+	if err != nil {
+		return reconcile.Result{}, errors.Wrap(err, "failed to populate cluster info")
+	}
+
+	// Set a finalizer so the user is deleted from the dashboard before the object goes away
+	err = opcontroller.AddFinalizerIfNotPresent(r.client, cephDashboardUser)
+	if err != nil {
+		return reconcile.Result{}, errors.Wrap(err, "failed to add finalizer")
+	}
+
+	// DELETE: the CR was deleted
+	if !cephDashboardUser.GetDeletionTimestamp().IsZero() {
+		for _, name := range []string{username(cephDashboardUser), previousUsername(cephDashboardUser)} {
+			if name == "" || name == mgr.DashboardUsername {
+				continue
+			}
+			logger.Infof("deleting dashboard user %q", name)
+			if err := r.deleteUser(clusterInfo, name); err != nil {
+				return reconcile.Result{}, errors.Wrapf(err, "failed to delete dashboard user %q", name)
+			}
+		}
+
+		err = opcontroller.RemoveFinalizer(r.client, cephDashboardUser)
+		if err != nil {
+			return reconcile.Result{}, errors.Wrap(err, "failed to remove finalizer")
+		}
+		return reconcile.Result{}, nil
+	}
+
+	// An invalid spec is not retried until the CR is updated
+	if err := validateUser(cephDashboardUser); err != nil {
+		logger.Errorf("failed to validate dashboard user %q. %v", request.NamespacedName, err)
+		updateStatus(r.client, request.NamespacedName, k8sutil.ReconcileFailedStatus, nil)
+		return reconcile.Result{}, nil
+	}
+
+	secretVersion, err := r.reconcileUser(clusterInfo, cephDashboardUser)
+	if err != nil {
+		updateStatus(r.client, request.NamespacedName, k8sutil.ReconcileFailedStatus, nil)
+		return reconcile.Result{}, errors.Wrapf(err, "failed to reconcile dashboard user %q", username(cephDashboardUser))
+	}
+
+	reconciled := &cephv1.DashboardUserStatus{Username: username(cephDashboardUser), PasswordSecretVersion: secretVersion}
+	updateStatus(r.client, request.NamespacedName, k8sutil.ReadyStatus, reconciled)
+	logger.Infof("dashboard user %q is up to date", username(cephDashboardUser))
+	return reconcile.Result{RequeueAfter: userRequeueInterval}, nil
+}
+
+// updateStatus updates an object with a given status. The username and the password secret version
+// of the reconciled user are recorded if it is not nil.
+func updateStatus(client client.Client, name types.NamespacedName, status string, reconciled *cephv1.DashboardUserStatus) {
+	user := &cephv1.CephDashboardUser{}
+	err := client.Get(context.TODO(), name, user)
+	if err != nil {
+		if kerrors.IsNotFound(err) {
+			logger.Debug("CephDashboardUser resource not found. Ignoring since object must be deleted.")
+			return
+		}
+		logger.Warningf("failed to retrieve dashboard user %q to update status to %q. %v", name, status, err)
+		return
+	}
+
+	if user.Status == nil {
+		user.Status = &cephv1.DashboardUserStatus{}
+	}
+
+	user.Status.Phase = status
+	if reconciled != nil {
+		user.Status.Username = reconciled.Username
+		user.Status.PasswordSecretVersion = reconciled.PasswordSecretVersion
+	}
+	if err := opcontroller.UpdateStatus(client, user); err != nil {
+		logger.Errorf("failed to set dashboard user %q status to %q. %v", user.Name, status, err)
+		return
+	}
+	logger.Debugf("dashboard user %q status updated to %q", name, status)
+}
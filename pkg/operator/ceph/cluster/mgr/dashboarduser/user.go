@@ -0,0 +1,202 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dashboarduser
+
+import (
+	"encoding/json"
+	"sort"
+	"syscall"
+
+	"github.com/pkg/errors"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	cephclient "github.com/rook/rook/pkg/daemon/ceph/client"
+	"github.com/rook/rook/pkg/operator/ceph/cluster/mgr"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	passwordKeyName = "password"
+)
+
+// dashboardUser is a user as reported by "ceph dashboard ac-user-show"
+type dashboardUser struct {
+	Username string   `json:"username"`
+	Roles    []string `json:"roles"`
+	Name     string   `json:"name"`
+	Email    string   `json:"email"`
+}
+
+// username returns the name of the dashboard user of the CR
+func username(user *cephv1.CephDashboardUser) string {
+	if user.Spec.Username != "" {
+		return user.Spec.Username
+	}
+	return user.Name
+}
+
+// previousUsername returns the name of the dashboard user last created for the CR if the username
+// of the spec changed since, or an empty string
+func previousUsername(user *cephv1.CephDashboardUser) string {
+	if user.Status == nil || user.Status.Username == username(user) {
+		return ""
+	}
+	return user.Status.Username
+}
+
+// validateUser rejects the users that cannot be managed with a CR
+func validateUser(user *cephv1.CephDashboardUser) error {
+	if username(user) == mgr.DashboardUsername {
+		return errors.Errorf("invalid username %q, the admin user of the dashboard is managed by the operator", mgr.DashboardUsername)
+	}
+	return nil
+}
+
+// passwordSecretVersion returns the resource version of the password secret last set on the user
+func passwordSecretVersion(user *cephv1.CephDashboardUser) string {
+	if user.Status == nil {
+		return ""
+	}
+	return user.Status.PasswordSecretVersion
+}
+
+// reconcileUser creates the dashboard user if it doesn't exist and updates its roles and info from
+// the spec. The password is only set again when the password secret changed, since setting it logs
+// the user out of the dashboard. The user previously created for the CR is deleted if the username
+// changed. It returns the resource version of the password secret set on the user.
+func (r *ReconcileCephDashboardUser) reconcileUser(clusterInfo *cephclient.ClusterInfo, user *cephv1.CephDashboardUser) (string, error) {
+	name := username(user)
+	password, secretVersion, err := r.getPassword(user)
+	if err != nil {
+		return "", err
+	}
+
+	if previous := previousUsername(user); previous != "" {
+		logger.Infof("deleting dashboard user %q renamed to %q", previous, name)
+		if err := r.deleteUser(clusterInfo, previous); err != nil {
+			return "", errors.Wrapf(err, "failed to delete renamed user %q", previous)
+		}
+	}
+
+	existing, err := r.getUser(clusterInfo, name)
+	if err != nil {
+		return "", err
+	}
+	if existing == nil {
+		logger.Infof("creating dashboard user %q", name)
+		if err := r.runDashboardCommand(clusterInfo, "ac-user-create", name, password); err != nil {
+			return "", errors.Wrap(err, "failed to create user")
+		}
+		existing = &dashboardUser{Username: name}
+	} else if secretVersion != passwordSecretVersion(user) {
+		logger.Infof("setting the password of dashboard user %q from the updated secret %q", name, user.Spec.PasswordSecret)
+		if err := r.runDashboardCommand(clusterInfo, "ac-user-set-password", name, password); err != nil {
+			return "", errors.Wrap(err, "failed to set the password")
+		}
+	}
+
+	if !sameRoles(existing.Roles, user.Spec.Roles) {
+		logger.Infof("setting the roles of dashboard user %q to %v", name, user.Spec.Roles)
+		if len(user.Spec.Roles) > 0 {
+			err = r.runDashboardCommand(clusterInfo, append([]string{"ac-user-set-roles", name}, user.Spec.Roles...)...)
+		} else {
+			err = r.runDashboardCommand(clusterInfo, append([]string{"ac-user-del-roles", name}, existing.Roles...)...)
+		}
+		if err != nil {
+			return "", errors.Wrap(err, "failed to set the roles")
+		}
+	}
+
+	if existing.Name != user.Spec.Name || existing.Email != user.Spec.Email {
+		if err := r.runDashboardCommand(clusterInfo, "ac-user-set-info", name, user.Spec.Name, user.Spec.Email); err != nil {
+			return "", errors.Wrap(err, "failed to set the name and email")
+		}
+	}
+	return secretVersion, nil
+}
+
+// getPassword returns the password of the user from its secret and the resource version of the secret
+func (r *ReconcileCephDashboardUser) getPassword(user *cephv1.CephDashboardUser) (string, string, error) {
+	if user.Spec.PasswordSecret == "" {
+		return "", "", errors.New("the password secret is required")
+	}
+	secret, err := r.context.Clientset.CoreV1().Secrets(user.Namespace).Get(user.Spec.PasswordSecret, metav1.GetOptions{})
+	if err != nil {
+		return "", "", errors.Wrapf(err, "failed to get password secret %q", user.Spec.PasswordSecret)
+	}
+	password, ok := secret.Data[passwordKeyName]
+	if !ok || len(password) == 0 {
+		return "", "", errors.Errorf("no %q key in password secret %q", passwordKeyName, user.Spec.PasswordSecret)
+	}
+	return string(password), secret.ResourceVersion, nil
+}
+
+// getUser returns the dashboard user, or nil if it doesn't exist
+func (r *ReconcileCephDashboardUser) getUser(clusterInfo *cephclient.ClusterInfo, name string) (*dashboardUser, error) {
+	args := []string{"dashboard", "ac-user-show", name}
+	output, err := cephclient.NewCephCommand(r.context, clusterInfo, args).Run()
+	if err != nil {
+		if code, ok := r.exitCode(err); ok && code == int(syscall.ENOENT) {
+			return nil, nil
+		}
+		return nil, errors.Wrapf(err, "failed to get dashboard user %q", name)
+	}
+
+	var user dashboardUser
+	if err := json.Unmarshal(output, &user); err != nil {
+		return nil, errors.Wrapf(err, "failed to parse dashboard user %q", name)
+	}
+	return &user, nil
+}
+
+// deleteUser deletes the dashboard user if it exists
+func (r *ReconcileCephDashboardUser) deleteUser(clusterInfo *cephclient.ClusterInfo, name string) error {
+	args := []string{"dashboard", "ac-user-delete", name}
+	if _, err := cephclient.NewCephCommand(r.context, clusterInfo, args).Run(); err != nil {
+		if code, ok := r.exitCode(err); ok && code == int(syscall.ENOENT) {
+			logger.Infof("dashboard user %q was already deleted", name)
+			return nil
+		}
+		return err
+	}
+	return nil
+}
+
+// runDashboardCommand runs a "ceph dashboard" command. The error only names the command since its
+// arguments can contain the password of the user.
+func (r *ReconcileCephDashboardUser) runDashboardCommand(clusterInfo *cephclient.ClusterInfo, args ...string) error {
+	_, err := cephclient.NewCephCommand(r.context, clusterInfo, append([]string{"dashboard"}, args...)).Run()
+	if err != nil {
+		return errors.Wrapf(err, "failed to run dashboard command %q", args[0])
+	}
+	return nil
+}
+
+func sameRoles(current, desired []string) bool {
+	if len(current) != len(desired) {
+		return false
+	}
+	a := append([]string{}, current...)
+	b := append([]string{}, desired...)
+	sort.Strings(a)
+	sort.Strings(b)
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
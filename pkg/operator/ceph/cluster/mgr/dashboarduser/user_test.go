@@ -0,0 +1,175 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dashboarduser
+
+import (
+	"encoding/json"
+	"syscall"
+	"testing"
+
+	"github.com/pkg/errors"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/clusterd"
+	cephclient "github.com/rook/rook/pkg/daemon/ceph/client"
+	exectest "github.com/rook/rook/pkg/util/exec/test"
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+var errNotFound = errors.New("user not found")
+
+func TestReconcileUser(t *testing.T) {
+	users := map[string]*dashboardUser{}
+	passwords := map[string]string{}
+	passwordUpdates := 0
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutputFile: func(command string, outFileArg string, args ...string) (string, error) {
+			if args[0] != "dashboard" {
+				return "", nil
+			}
+			user, exists := users[args[2]]
+			switch args[1] {
+			case "ac-user-show":
+				if !exists {
+					return "", errNotFound
+				}
+				output, err := json.Marshal(user)
+				return string(output), err
+			case "ac-user-create":
+				users[args[2]] = &dashboardUser{Username: args[2], Roles: []string{}}
+				passwords[args[2]] = args[3]
+			case "ac-user-set-password":
+				passwords[args[2]] = args[3]
+				passwordUpdates++
+			case "ac-user-set-roles":
+				user.Roles = []string{}
+				for _, arg := range args[3:] {
+					if arg[0] == '-' {
+						break
+					}
+					user.Roles = append(user.Roles, arg)
+				}
+			case "ac-user-del-roles":
+				user.Roles = []string{}
+			case "ac-user-set-info":
+				user.Name, user.Email = args[3], args[4]
+			case "ac-user-delete":
+				if !exists {
+					return "", errNotFound
+				}
+				delete(users, args[2])
+			}
+			return "", nil
+		},
+	}
+	secret := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "alice-password", Namespace: "ns", ResourceVersion: "1"},
+		Data:       map[string][]byte{"password": []byte("secret")},
+	}
+	clientset := fake.NewSimpleClientset(secret)
+	clusterInfo := cephclient.AdminClusterInfo("ns")
+	r := &ReconcileCephDashboardUser{
+		context: &clusterd.Context{Clientset: clientset, Executor: executor},
+		exitCode: func(err error) (int, bool) {
+			if err == errNotFound {
+				return int(syscall.ENOENT), true
+			}
+			return 0, false
+		},
+	}
+	user := &cephv1.CephDashboardUser{
+		ObjectMeta: metav1.ObjectMeta{Name: "alice", Namespace: "ns"},
+		Spec:       cephv1.DashboardUserSpec{PasswordSecret: "alice-password", Roles: []string{"read-only"}, Name: "Alice"},
+	}
+
+	// the user is created
+	version, err := r.reconcileUser(clusterInfo, user)
+	assert.NoError(t, err)
+	assert.Equal(t, "1", version)
+	assert.Equal(t, "secret", passwords["alice"])
+	assert.Equal(t, []string{"read-only"}, users["alice"].Roles)
+	assert.Equal(t, "Alice", users["alice"].Name)
+	user.Status = &cephv1.DashboardUserStatus{Username: "alice", PasswordSecretVersion: version}
+
+	// the roles and info are updated without setting the password again
+	user.Spec.Roles = []string{"block-manager", "pool-manager"}
+	user.Spec.Email = "alice@example.com"
+	_, err = r.reconcileUser(clusterInfo, user)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"block-manager", "pool-manager"}, users["alice"].Roles)
+	assert.Equal(t, "alice@example.com", users["alice"].Email)
+	assert.Equal(t, 0, passwordUpdates)
+
+	// the password is set again when the secret changed
+	secret.ResourceVersion = "2"
+	secret.Data["password"] = []byte("updated")
+	_, err = clientset.CoreV1().Secrets("ns").Update(secret)
+	assert.NoError(t, err)
+	version, err = r.reconcileUser(clusterInfo, user)
+	assert.NoError(t, err)
+	assert.Equal(t, "2", version)
+	assert.Equal(t, "updated", passwords["alice"])
+	assert.Equal(t, 1, passwordUpdates)
+	user.Status.PasswordSecretVersion = version
+
+	// the roles are removed
+	user.Spec.Roles = nil
+	_, err = r.reconcileUser(clusterInfo, user)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, len(users["alice"].Roles))
+	assert.Equal(t, 1, passwordUpdates)
+
+	// the renamed user is deleted
+	user.Spec.Username = "alice.smith"
+	assert.Equal(t, "alice", previousUsername(user))
+	_, err = r.reconcileUser(clusterInfo, user)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(users))
+	assert.Equal(t, "updated", passwords["alice.smith"])
+	user.Status.Username = "alice.smith"
+	assert.Equal(t, "", previousUsername(user))
+
+	// the password secret is required
+	user.Spec.PasswordSecret = "missing"
+	_, err = r.reconcileUser(clusterInfo, user)
+	assert.Error(t, err)
+
+	// the user is deleted once
+	assert.NoError(t, r.deleteUser(clusterInfo, "alice.smith"))
+	assert.Equal(t, 0, len(users))
+	assert.NoError(t, r.deleteUser(clusterInfo, "alice.smith"))
+}
+
+func TestValidateUser(t *testing.T) {
+	user := &cephv1.CephDashboardUser{ObjectMeta: metav1.ObjectMeta{Name: "alice", Namespace: "ns"}}
+	assert.NoError(t, validateUser(user))
+
+	// the admin user of the operator is reserved
+	user.Spec.Username = "admin"
+	assert.Error(t, validateUser(user))
+	user = &cephv1.CephDashboardUser{ObjectMeta: metav1.ObjectMeta{Name: "admin", Namespace: "ns"}}
+	assert.Error(t, validateUser(user))
+}
+
+func TestSameRoles(t *testing.T) {
+	assert.True(t, sameRoles(nil, []string{}))
+	assert.True(t, sameRoles([]string{"a", "b"}, []string{"b", "a"}))
+	assert.False(t, sameRoles([]string{"a"}, []string{"b"}))
+	assert.False(t, sameRoles([]string{"a"}, []string{"a", "b"}))
+}
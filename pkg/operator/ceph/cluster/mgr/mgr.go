@@ -20,6 +20,7 @@ package mgr
 import (
 	"fmt"
 	"path"
+	"sort"
 	"strconv"
 	"strings"
 
@@ -241,7 +242,7 @@ func (c *Cluster) enableBalancerModule() error {
 	// The order MATTERS, always configure this module first, then turn it on
 
 	// This sets min compat client to luminous and the balancer module mode
-	err := client.ConfigureBalancerModule(c.context, c.clusterInfo, balancerModuleMode)
+	err := client.ConfigureBalancerModule(c.context, c.clusterInfo, c.balancerMode())
 	if err != nil {
 		return errors.Wrapf(err, "failed to configure module %q", balancerModuleName)
 	}
@@ -272,7 +273,7 @@ func (c *Cluster) configureMgrModules() error {
 		if module.Enabled {
 			if module.Name == balancerModuleName {
 				// Configure balancer module mode
-				err := client.ConfigureBalancerModule(c.context, c.clusterInfo, balancerModuleMode)
+				err := client.ConfigureBalancerModule(c.context, c.clusterInfo, c.balancerMode())
 				if err != nil {
 					return errors.Wrapf(err, "failed to configure module %q", module.Name)
 				}
@@ -299,6 +300,10 @@ func (c *Cluster) configureMgrModules() error {
 				startModuleConfiguration("orchestrator modules", c.configureOrchestratorModules)
 			}
 
+			if err := c.configureModuleSettings(module); err != nil {
+				return errors.Wrapf(err, "failed to configure the settings of mgr module %q", module.Name)
+			}
+
 		} else {
 			if err := client.MgrDisableModule(c.context, c.clusterInfo, module.Name); err != nil {
 				return errors.Wrapf(err, "failed to disable mgr module %q", module.Name)
@@ -309,6 +314,44 @@ func (c *Cluster) configureMgrModules() error {
 	return nil
 }
 
+// configureModuleSettings sets the options of the module in the ceph config when they differ from
+// the spec, so changes made outside of the spec are reverted. Options removed from the spec keep
+// their current value.
+func (c *Cluster) configureModuleSettings(module cephv1.Module) error {
+	monStore := config.GetMonStore(c.context, c.clusterInfo)
+	keys := make([]string, 0, len(module.Settings))
+	for key := range module.Settings {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		option := fmt.Sprintf("mgr/%s/%s", module.Name, key)
+		value := module.Settings[key]
+		// the option is set if it cannot be read, e.g. when it was never set
+		current, err := monStore.Get("mgr", option)
+		if err == nil && strings.Trim(current, `"`) == value {
+			continue
+		}
+		logger.Infof("setting mgr module option %q to %q (current value %q)", option, value, current)
+		if err := monStore.Set("mgr", option, value); err != nil {
+			return errors.Wrapf(err, "failed to set mgr module option %q", option)
+		}
+	}
+	return nil
+}
+
+// balancerMode returns the mode of the balancer module from its settings in the spec, or the
+// upmap mode by default
+func (c *Cluster) balancerMode() string {
+	for _, module := range c.spec.Mgr.Modules {
+		if module.Name == balancerModuleName && module.Settings["mode"] != "" {
+			return module.Settings["mode"]
+		}
+	}
+	return balancerModuleMode
+}
+
 func (c *Cluster) moduleMeetsMinVersion(name string) (*cephver.CephVersion, bool) {
 	minVersions := map[string]cephver.CephVersion{
 		// Put the modules here, example:
@@ -196,6 +196,59 @@ func TestConfigureModules(t *testing.T) {
 	assert.Equal(t, 0, len(configSettings))
 }
 
+func TestConfigureModuleSettings(t *testing.T) {
+	mgrConfig := map[string]string{}
+	setCount := 0
+	balancerMode := ""
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutputFile: func(command string, outFileArg string, args ...string) (string, error) {
+			switch {
+			case args[0] == "config" && args[1] == "get" && args[2] == "mgr":
+				if value, ok := mgrConfig[args[3]]; ok {
+					return fmt.Sprintf("%q", value), nil
+				}
+				return "", fmt.Errorf("unrecognized key")
+			case args[0] == "config" && args[1] == "set" && args[2] == "mgr":
+				mgrConfig[args[3]] = args[4]
+				setCount++
+			case args[0] == "balancer" && args[1] == "mode":
+				balancerMode = args[2]
+			}
+			return "", nil
+		},
+	}
+
+	context := &clusterd.Context{Executor: executor, Clientset: testop.New(t, 1)}
+	c := &Cluster{context: context, clusterInfo: &cephclient.ClusterInfo{Namespace: "ns"}}
+	c.spec.Mgr.Modules = []cephv1.Module{
+		{Name: "telemetry", Enabled: true, Settings: map[string]string{"channel_basic": "true", "channel_crash": "false"}},
+		{Name: "balancer", Enabled: true, Settings: map[string]string{"mode": "crush-compat"}},
+	}
+
+	// the settings are set when they don't exist yet
+	assert.NoError(t, c.configureMgrModules())
+	assert.Equal(t, 3, setCount)
+	assert.Equal(t, "true", mgrConfig["mgr/telemetry/channel_basic"])
+	assert.Equal(t, "false", mgrConfig["mgr/telemetry/channel_crash"])
+	assert.Equal(t, "crush-compat", mgrConfig["mgr/balancer/mode"])
+	assert.Equal(t, "crush-compat", balancerMode)
+
+	// nothing is set when the settings match the spec
+	setCount = 0
+	assert.NoError(t, c.configureMgrModules())
+	assert.Equal(t, 0, setCount)
+
+	// a setting changed outside of the spec is reverted
+	mgrConfig["mgr/telemetry/channel_crash"] = "true"
+	assert.NoError(t, c.configureMgrModules())
+	assert.Equal(t, 1, setCount)
+	assert.Equal(t, "false", mgrConfig["mgr/telemetry/channel_crash"])
+
+	// the balancer uses the upmap mode by default
+	c.spec.Mgr.Modules[1].Settings = nil
+	assert.Equal(t, "upmap", c.balancerMode())
+}
+
 func TestMgrDaemons(t *testing.T) {
 	c := &Cluster{Replicas: 3}
 	daemons := c.getDaemonIDs()
@@ -78,6 +78,7 @@ func (c *Cluster) makeDeployment(mgrConfig *mgrConfig) (*apps.Deployment, error)
 	// ceph config set commands want admin keyring
 	podSpec.Spec.Volumes = append(podSpec.Spec.Volumes,
 		keyring.Volume().Admin())
+	c.addDashboardSSOVolume(&podSpec.Spec)
 	if c.spec.Network.IsHost() {
 		podSpec.Spec.DNSPolicy = v1.DNSClusterFirstWithHostNet
 	} else if c.spec.Network.NetworkSpec.IsMultus() {
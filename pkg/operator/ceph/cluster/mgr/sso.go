@@ -0,0 +1,138 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mgr
+
+import (
+	"path"
+
+	"github.com/pkg/errors"
+	"github.com/rook/rook/pkg/daemon/ceph/client"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	ssoVolumeName               = "dashboard-sso"
+	ssoSecretMountPath          = "/etc/ceph/dashboard-sso"
+	ssoIdPMetadataKey           = "idp-metadata.xml"
+	ssoDefaultUsernameAttribute = "uid"
+)
+
+// configureDashboardSSO sets up the SAML2 single sign-on of the dashboard, or disables it. Nothing
+// is done if the single sign-on is not in the spec.
+// Ceph docs about the dashboard sso: https://docs.ceph.com/docs/octopus/mgr/dashboard/#enabling-single-sign-on-sso
+func (c *Cluster) configureDashboardSSO() error {
+	sso := c.spec.Dashboard.SSO
+	if sso == nil {
+		return nil
+	}
+	if !sso.Enabled {
+		return c.runDashboardCommand("disable dashboard sso", "sso", "disable")
+	}
+
+	args, err := c.ssoSetupArgs()
+	if err != nil {
+		return errors.Wrap(err, "invalid dashboard sso settings")
+	}
+	logger.Infof("setting up the saml2 single sign-on of the dashboard with base url %q", sso.BaseURL)
+	if err := c.runDashboardCommand("set up dashboard sso", args...); err != nil {
+		return err
+	}
+	return c.runDashboardCommand("enable dashboard sso", "sso", "enable", "saml2")
+}
+
+// ssoSetupArgs returns the arguments of the "dashboard sso setup saml2" command. The files of the
+// secret are read by the mgr from the secret volume of the mgr pod.
+func (c *Cluster) ssoSetupArgs() ([]string, error) {
+	sso := c.spec.Dashboard.SSO
+	if sso.BaseURL == "" {
+		return nil, errors.New("the base url of the dashboard is required")
+	}
+
+	secretData := map[string][]byte{}
+	if sso.SecretName != "" {
+		secret, err := c.context.Clientset.CoreV1().Secrets(c.clusterInfo.Namespace).Get(sso.SecretName, metav1.GetOptions{})
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to get dashboard sso secret %q", sso.SecretName)
+		}
+		secretData = secret.Data
+	}
+
+	idpMetadata := sso.IdPMetadataURL
+	if idpMetadata == "" {
+		if _, ok := secretData[ssoIdPMetadataKey]; !ok {
+			return nil, errors.Errorf("the idp metadata url or the %q key of the secret is required", ssoIdPMetadataKey)
+		}
+		idpMetadata = path.Join(ssoSecretMountPath, ssoIdPMetadataKey)
+	}
+	usernameAttribute := sso.IdPUsernameAttribute
+	if usernameAttribute == "" {
+		usernameAttribute = ssoDefaultUsernameAttribute
+	}
+	args := []string{"sso", "setup", "saml2", sso.BaseURL, idpMetadata, usernameAttribute}
+
+	// the optional arguments are positional, the certificate can only be passed after the entity id
+	_, hasCert := secretData[v1.TLSCertKey]
+	_, hasKey := secretData[v1.TLSPrivateKeyKey]
+	if hasCert != hasKey {
+		return nil, errors.Errorf("the secret must have both the %q and %q keys to sign the requests", v1.TLSCertKey, v1.TLSPrivateKeyKey)
+	}
+	if hasCert && sso.IdPEntityID == "" {
+		return nil, errors.New("the idp entity id is required to sign the requests")
+	}
+	if sso.IdPEntityID != "" {
+		args = append(args, sso.IdPEntityID)
+	}
+	if hasCert {
+		args = append(args, path.Join(ssoSecretMountPath, v1.TLSCertKey), path.Join(ssoSecretMountPath, v1.TLSPrivateKeyKey))
+	}
+	return args, nil
+}
+
+// runDashboardCommand runs a "ceph dashboard" command, retrying while the dashboard module is not
+// ready or the files of the sso secret are not mounted yet in the mgr pod
+func (c *Cluster) runDashboardCommand(action string, args ...string) error {
+	_, err := client.ExecuteCephCommandWithRetry(func() (string, []byte, error) {
+		cmd := client.NewCephCommand(c.context, c.clusterInfo, append([]string{"dashboard"}, args...))
+		output, err := cmd.RunWithTimeout(client.CmdExecuteTimeout)
+		return action, output, err
+	}, c.exitCode, 5, invalidArgErrorCode, dashboardInitWaitTime)
+	if err != nil {
+		return errors.Wrapf(err, "failed to %s", action)
+	}
+	return nil
+}
+
+// addDashboardSSOVolume mounts the sso secret in the mgr container so the mgr can read its files
+func (c *Cluster) addDashboardSSOVolume(podSpec *v1.PodSpec) {
+	sso := c.spec.Dashboard.SSO
+	if !c.spec.Dashboard.Enabled || sso == nil || !sso.Enabled || sso.SecretName == "" {
+		return
+	}
+	podSpec.Volumes = append(podSpec.Volumes, v1.Volume{
+		Name: ssoVolumeName,
+		VolumeSource: v1.VolumeSource{
+			Secret: &v1.SecretVolumeSource{SecretName: sso.SecretName},
+		},
+	})
+	for i := range podSpec.Containers {
+		if podSpec.Containers[i].Name == "mgr" {
+			podSpec.Containers[i].VolumeMounts = append(podSpec.Containers[i].VolumeMounts,
+				v1.VolumeMount{Name: ssoVolumeName, MountPath: ssoSecretMountPath, ReadOnly: true})
+		}
+	}
+}
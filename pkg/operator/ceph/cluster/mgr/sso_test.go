@@ -0,0 +1,119 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mgr
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/clusterd"
+	cephclient "github.com/rook/rook/pkg/daemon/ceph/client"
+	"github.com/rook/rook/pkg/operator/test"
+	exectest "github.com/rook/rook/pkg/util/exec/test"
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestConfigureDashboardSSO(t *testing.T) {
+	commands := []string{}
+	executor := &exectest.MockExecutor{}
+	executor.MockExecuteCommandWithOutputFileTimeout = func(timeout time.Duration, command, outfileArg string, args ...string) (string, error) {
+		// skip the connection flags
+		for i, arg := range args {
+			if strings.HasPrefix(arg, "--") {
+				args = args[:i]
+				break
+			}
+		}
+		commands = append(commands, strings.Join(args, " "))
+		return "", nil
+	}
+	clientset := test.New(t, 1)
+	c := &Cluster{
+		context:     &clusterd.Context{Clientset: clientset, Executor: executor},
+		clusterInfo: &cephclient.ClusterInfo{Namespace: "ns"},
+	}
+	c.exitCode = func(err error) (int, bool) { return 0, false }
+
+	// nothing is done without sso settings
+	assert.NoError(t, c.configureDashboardSSO())
+	assert.Equal(t, 0, len(commands))
+
+	// the base url is required
+	c.spec.Dashboard.SSO = &cephv1.DashboardSSOSpec{Enabled: true, IdPMetadataURL: "https://idp/metadata"}
+	assert.Error(t, c.configureDashboardSSO())
+
+	// the metadata from the url
+	c.spec.Dashboard.SSO.BaseURL = "https://dashboard"
+	assert.NoError(t, c.configureDashboardSSO())
+	assert.Equal(t, []string{
+		"dashboard sso setup saml2 https://dashboard https://idp/metadata uid",
+		"dashboard sso enable saml2",
+	}, commands[:2])
+
+	// the metadata is required
+	c.spec.Dashboard.SSO.IdPMetadataURL = ""
+	c.spec.Dashboard.SSO.SecretName = "sso"
+	secret := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "sso", Namespace: "ns"},
+		Data:       map[string][]byte{v1.TLSCertKey: []byte("cert"), v1.TLSPrivateKeyKey: []byte("key")},
+	}
+	_, err := clientset.CoreV1().Secrets("ns").Create(secret)
+	assert.NoError(t, err)
+	_, err = c.ssoSetupArgs()
+	assert.Error(t, err)
+
+	// the entity id is required with the certificate
+	secret.Data[ssoIdPMetadataKey] = []byte("<xml/>")
+	_, err = clientset.CoreV1().Secrets("ns").Update(secret)
+	assert.NoError(t, err)
+	_, err = c.ssoSetupArgs()
+	assert.Error(t, err)
+
+	// the metadata and the certificate from the secret
+	c.spec.Dashboard.SSO.IdPEntityID = "idp"
+	c.spec.Dashboard.SSO.IdPUsernameAttribute = "email"
+	args, err := c.ssoSetupArgs()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"sso", "setup", "saml2", "https://dashboard", "/etc/ceph/dashboard-sso/idp-metadata.xml", "email", "idp",
+		"/etc/ceph/dashboard-sso/tls.crt", "/etc/ceph/dashboard-sso/tls.key"}, args)
+
+	// disable the sso
+	commands = []string{}
+	c.spec.Dashboard.SSO.Enabled = false
+	assert.NoError(t, c.configureDashboardSSO())
+	assert.Equal(t, []string{"dashboard sso disable"}, commands)
+}
+
+func TestAddDashboardSSOVolume(t *testing.T) {
+	c := &Cluster{}
+	podSpec := v1.PodSpec{Containers: []v1.Container{{Name: "mgr"}}}
+
+	// no volume without the sso secret
+	c.spec.Dashboard = cephv1.DashboardSpec{Enabled: true, SSO: &cephv1.DashboardSSOSpec{Enabled: true}}
+	c.addDashboardSSOVolume(&podSpec)
+	assert.Equal(t, 0, len(podSpec.Volumes))
+
+	c.spec.Dashboard.SSO.SecretName = "sso"
+	c.addDashboardSSOVolume(&podSpec)
+	assert.Equal(t, 1, len(podSpec.Volumes))
+	assert.Equal(t, "sso", podSpec.Volumes[0].Secret.SecretName)
+	assert.Equal(t, ssoSecretMountPath, podSpec.Containers[0].VolumeMounts[0].MountPath)
+}
@@ -97,6 +97,16 @@ func (c *Cluster) checkHealth() error {
 		return errors.New("skipping mon health check since cluster details are not initialized")
 	}
 
+	// The mons must not be failed over while an admin performs a manual maintenance
+	paused, err := controller.IsClusterReconcilePaused(c.context.Client, c.Namespace)
+	if err != nil {
+		return errors.Wrap(err, "failed to check whether the cluster reconcile is paused")
+	}
+	if paused {
+		logger.Debugf("skipping mon health check since the reconcile of the cluster in namespace %q is paused", c.Namespace)
+		return nil
+	}
+
 	// If the cluster is converged and no mons were specified
 	if c.spec.Mon.Count == 0 && !c.spec.External.Enable {
 		return errors.New("skipping mon health check since there are no monitors")
@@ -133,6 +143,19 @@ func (c *Cluster) checkHealth() error {
 		monsNotFound[mon.Name] = struct{}{}
 	}
 
+	// warn when the quorum would not survive the loss of another mon. note that an even number
+	// of mons does not tolerate more failures than the odd number right below it.
+	monsOutOfQuorum := 0
+	for _, mon := range quorumStatus.MonMap.Mons {
+		if !monInQuorum(mon, quorumStatus.Quorum) {
+			monsOutOfQuorum++
+		}
+	}
+	if monsOutOfQuorum > 0 && monsOutOfQuorum >= quorumTolerance(len(quorumStatus.MonMap.Mons)) {
+		logger.Warningf("%d of %d mons are out of quorum, the quorum will be lost if another mon fails",
+			monsOutOfQuorum, len(quorumStatus.MonMap.Mons))
+	}
+
 	// first handle mons that are not in quorum but in the ceph mon map
 	// failover the unhealthy mons
 	allMonsInQuorum := true
@@ -223,6 +246,17 @@ func (c *Cluster) checkHealth() error {
 		}
 	}
 
+	// migrate the mons to the storage class of the volume claim template one at a time, only when
+	// all the mons are healthy
+	if allMonsInQuorum && len(quorumStatus.MonMap.Mons) == desiredMonCount {
+		migrated, err := c.migrateMonStorage()
+		if err != nil || migrated {
+			// the canaries left by a failed migration are removed
+			c.removeCanaryDeployments()
+			return err
+		}
+	}
+
 	// remove any pending/not needed mon canary deployment if everything is ok
 	if allMonsInQuorum && len(quorumStatus.MonMap.Mons) == desiredMonCount {
 		logger.Debug("mon cluster is healthy, removing any existing canary deployment")
@@ -291,7 +325,16 @@ func (c *Cluster) failoverMon(name string) error {
 		}
 	}()
 
-	// Start a new monitor
+	if err := c.startNewMon(); err != nil {
+		return err
+	}
+	newMonSucceeded = true
+
+	return c.removeMon(name)
+}
+
+// startNewMon starts a new monitor and waits for it to join the quorum
+func (c *Cluster) startNewMon() error {
 	m := c.newMonConfig(c.maxMonID + 1)
 	logger.Infof("starting new mon: %+v", m)
 
@@ -325,15 +368,30 @@ func (c *Cluster) failoverMon(name string) error {
 
 	// Only increment the max mon id if the new pod started successfully
 	c.maxMonID++
-	newMonSucceeded = true
-
-	return c.removeMon(name)
+	return nil
 }
 
 // make a best effort to remove the mon and all its resources
 func (c *Cluster) removeMon(daemonName string) error {
 	logger.Infof("ensuring removal of unhealthy monitor %s", daemonName)
 
+	c.removeMonResources(daemonName)
+
+	// Remove the bad monitor from quorum
+	if err := c.removeMonitorFromQuorum(daemonName); err != nil {
+		logger.Errorf("failed to remove mon %q from quorum. %v", daemonName, err)
+	}
+
+	if err := c.saveMonConfig(); err != nil {
+		return errors.Wrapf(err, "failed to save mon config after failing over mon %s", daemonName)
+	}
+
+	return nil
+}
+
+// removeMonResources makes a best effort to remove the deployment, service and pvc of the mon
+// and to forget the mon. The mon is not removed from quorum.
+func (c *Cluster) removeMonResources(daemonName string) {
 	resourceName := resourceName(daemonName)
 
 	// Remove the mon pod if it is still there
@@ -348,10 +406,6 @@ func (c *Cluster) removeMon(daemonName string) error {
 		}
 	}
 
-	// Remove the bad monitor from quorum
-	if err := c.removeMonitorFromQuorum(daemonName); err != nil {
-		logger.Errorf("failed to remove mon %q from quorum. %v", daemonName, err)
-	}
 	delete(c.ClusterInfo.Monitors, daemonName)
 	// check if a mapping exists for the mon
 	if _, ok := c.mapping.Node[daemonName]; ok {
@@ -375,12 +429,6 @@ func (c *Cluster) removeMon(daemonName string) error {
 			logger.Errorf("failed to remove dead mon pvc %q. %v", resourceName, err)
 		}
 	}
-
-	if err := c.saveMonConfig(); err != nil {
-		return errors.Wrapf(err, "failed to save mon config after failing over mon %s", daemonName)
-	}
-
-	return nil
 }
 
 func (c *Cluster) removeMonitorFromQuorum(name string) error {
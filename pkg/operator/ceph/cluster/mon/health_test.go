@@ -17,6 +17,7 @@ limitations under the License.
 package mon
 
 import (
+	ctx "context"
 	"fmt"
 	"io/ioutil"
 	"os"
@@ -26,10 +27,12 @@ import (
 	"time"
 
 	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/client/clientset/versioned/scheme"
 	"github.com/rook/rook/pkg/clusterd"
 	"github.com/rook/rook/pkg/daemon/ceph/client"
 	clienttest "github.com/rook/rook/pkg/daemon/ceph/client/test"
 	"github.com/rook/rook/pkg/operator/ceph/config"
+	"github.com/rook/rook/pkg/operator/ceph/controller"
 	"github.com/rook/rook/pkg/operator/k8sutil"
 	testopk8s "github.com/rook/rook/pkg/operator/k8sutil/test"
 	"github.com/rook/rook/pkg/operator/test"
@@ -41,6 +44,7 @@ import (
 	kerrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 )
 
 func TestCheckHealth(t *testing.T) {
@@ -58,6 +62,7 @@ func TestCheckHealth(t *testing.T) {
 	defer os.RemoveAll(configDir)
 	context := &clusterd.Context{
 		Clientset: clientset,
+		Client:    fake.NewFakeClientWithScheme(scheme.Scheme),
 		ConfigDir: configDir,
 		Executor:  executor,
 	}
@@ -88,6 +93,15 @@ func TestCheckHealth(t *testing.T) {
 		return SchedulingResult{Node: node}, nil
 	}
 
+	// nothing is done while the cluster reconcile is paused
+	cluster := &cephv1.CephCluster{ObjectMeta: metav1.ObjectMeta{Name: "ceph", Namespace: "ns",
+		Annotations: map[string]string{controller.PauseReconcileAnnotation: "true"}}}
+	assert.NoError(t, context.Client.Create(ctx.TODO(), cluster))
+	err = c.checkHealth()
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(testopk8s.DeploymentNamesUpdated(deploymentsUpdated)))
+	assert.NoError(t, context.Client.Delete(ctx.TODO(), cluster))
+
 	err = c.checkHealth()
 	assert.Nil(t, err)
 	logger.Infof("mons after checkHealth: %v", c.ClusterInfo.Monitors)
@@ -153,6 +167,7 @@ func TestCheckHealthNotFound(t *testing.T) {
 	defer os.RemoveAll(configDir)
 	context := &clusterd.Context{
 		Clientset: clientset,
+		Client:    fake.NewFakeClientWithScheme(scheme.Scheme),
 		ConfigDir: configDir,
 		Executor:  executor,
 	}
@@ -209,6 +224,7 @@ func TestAddRemoveMons(t *testing.T) {
 	defer os.RemoveAll(configDir)
 	context := &clusterd.Context{
 		Clientset: clientset,
+		Client:    fake.NewFakeClientWithScheme(scheme.Scheme),
 		ConfigDir: configDir,
 		Executor:  executor,
 	}
@@ -0,0 +1,133 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mon
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/pkg/errors"
+	"github.com/rook/rook/pkg/operator/k8sutil"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// migrateMonStorage replaces a mon running on a PVC of another storage class than the one of the
+// mon volume claim template. If a node is free for another mon, the new mon is started on a PVC of
+// the new storage class and joins the quorum before the old mon is removed, so all the mons stay in
+// quorum during the migration. Otherwise the old mon is stopped to free its node and replaced the
+// same way as a failed mon, the other mons keep the quorum. At most one mon is migrated per call,
+// returns whether a mon was migrated.
+func (c *Cluster) migrateMonStorage() (bool, error) {
+	name, err := c.monToMigrate()
+	if err != nil || name == "" {
+		return false, err
+	}
+
+	spare, err := c.hasSpareMonNode()
+	if err != nil {
+		return false, errors.Wrapf(err, "failed to find a node for the mon replacing mon %q", name)
+	}
+	if !spare {
+		logger.Infof("migrating mon %q to storage class %q by replacing it, no node is free for another mon", name, *c.spec.Mon.VolumeClaimTemplate.Spec.StorageClassName)
+		if err := c.failoverMon(name); err != nil {
+			return false, errors.Wrapf(err, "failed to replace mon %q", name)
+		}
+		return true, nil
+	}
+
+	logger.Infof("migrating mon %q to storage class %q", name, *c.spec.Mon.VolumeClaimTemplate.Spec.StorageClassName)
+	if err := c.startNewMon(); err != nil {
+		return false, errors.Wrapf(err, "failed to start a new mon to replace mon %q", name)
+	}
+	if err := c.removeMon(name); err != nil {
+		return true, errors.Wrapf(err, "failed to remove mon %q after its migration", name)
+	}
+	return true, nil
+}
+
+// hasSpareMonNode returns whether a new mon can be scheduled while all the mons are running. The
+// mons can always be scheduled if several mons are allowed on a node, otherwise a node valid for the
+// mon placement must not run a mon.
+func (c *Cluster) hasSpareMonNode() (bool, error) {
+	if !requiredDuringScheduling(&c.spec) {
+		return true, nil
+	}
+
+	pods, err := c.context.Clientset.CoreV1().Pods(c.Namespace).List(metav1.ListOptions{LabelSelector: fmt.Sprintf("%s=%s", k8sutil.AppAttr, AppName)})
+	if err != nil {
+		return false, errors.Wrap(err, "failed to list the mon pods")
+	}
+	monNodes := map[string]bool{}
+	for _, pod := range pods.Items {
+		if pod.Spec.NodeName != "" {
+			monNodes[pod.Spec.NodeName] = true
+		}
+	}
+
+	nodes, err := c.context.Clientset.CoreV1().Nodes().List(metav1.ListOptions{})
+	if err != nil {
+		return false, errors.Wrap(err, "failed to list nodes")
+	}
+	p := c.monPlacement()
+	for _, node := range nodes.Items {
+		if monNodes[node.Name] {
+			continue
+		}
+		valid, err := k8sutil.ValidNode(node, p)
+		if err != nil {
+			logger.Warningf("failed to validate node %q for the mons. %v", node.Name, err)
+			continue
+		}
+		if valid {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// monToMigrate returns the name of the first mon whose PVC is not of the storage class of the mon
+// volume claim template, or an empty string if there is none. The mons on host paths are not
+// migrated. If the template doesn't set a storage class, the default storage class is used for the
+// new mons, so there is nothing to compare the PVCs with.
+func (c *Cluster) monToMigrate() (string, error) {
+	template := c.spec.Mon.VolumeClaimTemplate
+	if template == nil || template.Spec.StorageClassName == nil {
+		return "", nil
+	}
+	storageClass := *template.Spec.StorageClassName
+
+	names := []string{}
+	for name := range c.ClusterInfo.Monitors {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		pvc, err := c.context.Clientset.CoreV1().PersistentVolumeClaims(c.Namespace).Get(resourceName(name), metav1.GetOptions{})
+		if err != nil {
+			if kerrors.IsNotFound(err) {
+				continue
+			}
+			return "", errors.Wrapf(err, "failed to get the pvc of mon %q", name)
+		}
+		if pvc.Spec.StorageClassName == nil || *pvc.Spec.StorageClassName != storageClass {
+			return name, nil
+		}
+	}
+	return "", nil
+}
@@ -0,0 +1,125 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mon
+
+import (
+	"testing"
+
+	"github.com/rook/rook/pkg/clusterd"
+	cephclient "github.com/rook/rook/pkg/daemon/ceph/client"
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func newMigrateTestPVC(name, storageClass string) *v1.PersistentVolumeClaim {
+	return &v1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{Name: resourceName(name), Namespace: "ns"},
+		Spec:       v1.PersistentVolumeClaimSpec{StorageClassName: &storageClass},
+	}
+}
+
+func TestMonToMigrate(t *testing.T) {
+	clientset := fake.NewSimpleClientset(
+		newMigrateTestPVC("a", "gp2"),
+		newMigrateTestPVC("b", "gp2"),
+	)
+	c := newCluster(&clusterd.Context{Clientset: clientset}, "ns", false, v1.ResourceRequirements{})
+	c.ClusterInfo = &cephclient.ClusterInfo{
+		Monitors: map[string]*cephclient.MonInfo{
+			"a": cephclient.NewMonInfo("a", "1.2.3.1", 6789),
+			"b": cephclient.NewMonInfo("b", "1.2.3.2", 6789),
+			"c": cephclient.NewMonInfo("c", "1.2.3.3", 6789),
+		},
+	}
+
+	// mons on host paths
+	name, err := c.monToMigrate()
+	assert.NoError(t, err)
+	assert.Equal(t, "", name)
+
+	// no storage class to compare with
+	c.spec.Mon.VolumeClaimTemplate = &v1.PersistentVolumeClaim{}
+	name, err = c.monToMigrate()
+	assert.NoError(t, err)
+	assert.Equal(t, "", name)
+
+	// the mons are on the expected storage class, mon c is on a host path
+	storageClass := "gp2"
+	c.spec.Mon.VolumeClaimTemplate.Spec.StorageClassName = &storageClass
+	name, err = c.monToMigrate()
+	assert.NoError(t, err)
+	assert.Equal(t, "", name)
+
+	// the storage class changed
+	storageClass = "io1"
+	name, err = c.monToMigrate()
+	assert.NoError(t, err)
+	assert.Equal(t, "a", name)
+
+	// mon a was migrated
+	_, err = clientset.CoreV1().PersistentVolumeClaims("ns").Update(newMigrateTestPVC("a", "io1"))
+	assert.NoError(t, err)
+	name, err = c.monToMigrate()
+	assert.NoError(t, err)
+	assert.Equal(t, "b", name)
+}
+
+func TestHasSpareMonNode(t *testing.T) {
+	newMonPod := func(name, nodeName string) *v1.Pod {
+		return &v1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "ns", Labels: map[string]string{"app": AppName}},
+			Spec:       v1.PodSpec{NodeName: nodeName},
+		}
+	}
+	clientset := fake.NewSimpleClientset(
+		newTopologyTestNode("node1", ""),
+		newTopologyTestNode("node2", ""),
+		newMonPod("rook-ceph-mon-a", "node1"),
+		newMonPod("rook-ceph-mon-b", "node2"),
+	)
+	c := newCluster(&clusterd.Context{Clientset: clientset}, "ns", false, v1.ResourceRequirements{})
+
+	// a mon runs on every node
+	spare, err := c.hasSpareMonNode()
+	assert.NoError(t, err)
+	assert.False(t, spare)
+
+	// several mons are allowed on a node
+	c.spec.Mon.AllowMultiplePerNode = true
+	spare, err = c.hasSpareMonNode()
+	assert.NoError(t, err)
+	assert.True(t, spare)
+	c.spec.Mon.AllowMultiplePerNode = false
+
+	// a new node is free
+	_, err = clientset.CoreV1().Nodes().Create(newTopologyTestNode("node3", ""))
+	assert.NoError(t, err)
+	spare, err = c.hasSpareMonNode()
+	assert.NoError(t, err)
+	assert.True(t, spare)
+
+	// the free node is cordoned
+	node3 := newTopologyTestNode("node3", "")
+	node3.Spec.Unschedulable = true
+	_, err = clientset.CoreV1().Nodes().Update(node3)
+	assert.NoError(t, err)
+	spare, err = c.hasSpareMonNode()
+	assert.NoError(t, err)
+	assert.False(t, spare)
+}
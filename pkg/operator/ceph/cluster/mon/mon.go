@@ -205,6 +205,10 @@ func (c *Cluster) Start(clusterInfo *cephclient.ClusterInfo, rookVersion string,
 		return nil, errors.Wrap(err, "error checking pod memory")
 	}
 
+	if err := c.validateMonTopologySpread(); err != nil {
+		return nil, errors.Wrap(err, "invalid mon topology spread constraints")
+	}
+
 	logger.Infof("start running mons")
 
 	logger.Debugf("establishing ceph cluster info")
@@ -435,7 +439,7 @@ func realScheduleMonitor(c *Cluster, mon *monConfig) (SchedulingResult, error) {
 	d.Spec.Template.Spec.Containers[0].LivenessProbe = nil
 
 	// setup affinity settings for pod scheduling
-	p := cephv1.GetMonPlacement(c.spec.Placement)
+	p := c.monPlacement()
 	k8sutil.SetNodeAntiAffinityForPod(&d.Spec.Template.Spec, p, requiredDuringScheduling(&c.spec), PreferredDuringScheduling,
 		map[string]string{k8sutil.AppAttr: AppName}, nil)
 
@@ -895,7 +899,7 @@ func (c *Cluster) startMon(m *monConfig, node *NodeInfo) error {
 	}
 
 	// placement settings from the CRD
-	p := cephv1.GetMonPlacement(c.spec.Placement)
+	p := c.monPlacement()
 
 	if deploymentExists {
 		// the existing deployment may have a node selector. if the cluster
@@ -905,6 +909,7 @@ func (c *Cluster) startMon(m *monConfig, node *NodeInfo) error {
 		if c.spec.Network.IsHost() || !pvcExists {
 			p.PodAffinity = nil
 			p.PodAntiAffinity = nil
+			p.TopologySpreadConstraints = nil
 			k8sutil.SetNodeAntiAffinityForPod(&d.Spec.Template.Spec, p, requiredDuringScheduling(&c.spec), PreferredDuringScheduling,
 				map[string]string{k8sutil.AppAttr: AppName}, existingDeployment.Spec.Template.Spec.NodeSelector)
 		} else {
@@ -933,8 +938,10 @@ func (c *Cluster) startMon(m *monConfig, node *NodeInfo) error {
 		k8sutil.SetNodeAntiAffinityForPod(&d.Spec.Template.Spec, p, requiredDuringScheduling(&c.spec), PreferredDuringScheduling,
 			map[string]string{k8sutil.AppAttr: AppName}, nil)
 	} else {
+		// the node was chosen by the canary, which already honored the affinity and spread rules
 		p.PodAffinity = nil
 		p.PodAntiAffinity = nil
+		p.TopologySpreadConstraints = nil
 		k8sutil.SetNodeAntiAffinityForPod(&d.Spec.Template.Spec, p, requiredDuringScheduling(&c.spec), PreferredDuringScheduling,
 			map[string]string{k8sutil.AppAttr: AppName}, map[string]string{v1.LabelHostname: node.Hostname})
 	}
@@ -1061,6 +1068,25 @@ func requiredDuringScheduling(spec *cephv1.ClusterSpec) bool {
 	return spec.Network.IsHost() || !spec.Mon.AllowMultiplePerNode
 }
 
+// MonCountQuorumRisk returns a message describing the quorum risk of the mon count of the cluster
+// spec, or an empty string if there is no risk. An even count does not tolerate more mon failures
+// than the odd count right below it.
+func MonCountQuorumRisk(spec cephv1.MonSpec) string {
+	if spec.Count%2 != 0 {
+		return ""
+	}
+	return fmt.Sprintf("mon count %d is even, the quorum only tolerates the loss of %d mon(s) like with %d mon(s)",
+		spec.Count, quorumTolerance(spec.Count), spec.Count-1)
+}
+
+// quorumTolerance returns the number of mons that can be lost while the other mons keep the quorum
+func quorumTolerance(monCount int) int {
+	if monCount < 1 {
+		return 0
+	}
+	return (monCount - 1) / 2
+}
+
 func (c *Cluster) acquireOrchestrationLock() {
 	logger.Debugf("Acquiring lock for mon orchestration")
 	c.orchestrationMutex.Lock()
@@ -345,3 +345,22 @@ func TestMonFoundInQuorum(t *testing.T) {
 	assert.True(t, monFoundInQuorum("c", response))
 	assert.False(t, monFoundInQuorum("d", response))
 }
+
+func TestMonCountQuorumRisk(t *testing.T) {
+	assert.Equal(t, "", MonCountQuorumRisk(cephv1.MonSpec{Count: 3}))
+
+	// the risk of an even count is reported whether it is allowed or not
+	risk := "mon count 4 is even, the quorum only tolerates the loss of 1 mon(s) like with 3 mon(s)"
+	assert.Equal(t, risk, MonCountQuorumRisk(cephv1.MonSpec{Count: 4}))
+	assert.Equal(t, risk, MonCountQuorumRisk(cephv1.MonSpec{Count: 4, AllowEvenCount: true}))
+}
+
+func TestQuorumTolerance(t *testing.T) {
+	assert.Equal(t, 0, quorumTolerance(0))
+	assert.Equal(t, 0, quorumTolerance(1))
+	assert.Equal(t, 0, quorumTolerance(2))
+	assert.Equal(t, 1, quorumTolerance(3))
+	assert.Equal(t, 1, quorumTolerance(4))
+	assert.Equal(t, 2, quorumTolerance(5))
+	assert.Equal(t, 2, quorumTolerance(6))
+}
@@ -0,0 +1,285 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mon
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	cephclient "github.com/rook/rook/pkg/daemon/ceph/client"
+	"github.com/rook/rook/pkg/operator/ceph/config"
+	"github.com/rook/rook/pkg/operator/ceph/controller"
+	cephver "github.com/rook/rook/pkg/operator/ceph/version"
+	"github.com/rook/rook/pkg/operator/k8sutil"
+	apps "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// RestoreQuorumAnnotation is the annotation of the CephCluster requesting the mon quorum to be
+	// restored from the single healthy mon named in its value, e.g. "b". The annotation is removed
+	// once the restore was attempted.
+	RestoreQuorumAnnotation = "ceph.rook.io/restore-mon-quorum"
+
+	restoreQuorumContainerName = "restore-quorum"
+	restoreQuorumMonmapPath    = "/tmp/monmap"
+)
+
+var (
+	// restoreQuorumTimeout is the time to wait for the healthy mon to start with the new monmap
+	restoreQuorumTimeout = 10 * time.Minute
+	// the quorum is checked this many times, restoreQuorumCheckInterval apart, to confirm it is lost
+	// before the restore and to wait for it after the restore
+	restoreQuorumChecks        = 5
+	restoreQuorumCheckInterval = 15 * time.Second
+	// monAdminSocketCommand runs a command on the admin socket of a mon, it is mocked in the tests
+	monAdminSocketCommand = runMonAdminSocketCommand
+)
+
+// monAdminSocketStatus is the status of a mon as reported by its admin socket
+type monAdminSocketStatus struct {
+	Name   string `json:"name"`
+	State  string `json:"state"`
+	Quorum []int  `json:"quorum"`
+}
+
+// RestoreQuorum restores the mon quorum from a single healthy mon when the mons cannot form quorum
+// anymore. This automates the disaster recovery procedure for the mons. The other mons are removed
+// along with their deployment, service and pvc, and the mon endpoints are updated to only contain
+// the healthy mon. Then the monmap of the healthy mon is extracted, the other mons are removed from
+// it and it is injected back before the healthy mon is restarted.
+// As a guard, nothing is done unless the quorum is lost on several consecutive checks and the
+// admin socket of the healthy mon confirms it is not in quorum. Once the healthy mon forms a
+// quorum on its own, the init container updating the monmap is removed and the next orchestration
+// grows the quorum back to the desired mon count.
+func (c *Cluster) RestoreQuorum(cephVersion cephver.CephVersion, spec cephv1.ClusterSpec, healthyMon string) error {
+	c.acquireOrchestrationLock()
+	defer c.releaseOrchestrationLock()
+
+	c.spec = spec
+	var err error
+	c.ClusterInfo, c.maxMonID, c.mapping, err = LoadClusterInfo(c.context, c.Namespace)
+	if err != nil {
+		return errors.Wrap(err, "failed to load the cluster info")
+	}
+	c.ClusterInfo.CephVersion = cephVersion
+	c.ClusterInfo.OwnerRef = c.ownerRef
+
+	if _, ok := c.ClusterInfo.Monitors[healthyMon]; !ok {
+		return errors.Errorf("cannot restore the mon quorum from unknown mon %q", healthyMon)
+	}
+	badMons := []string{}
+	for name := range c.ClusterInfo.Monitors {
+		if name != healthyMon {
+			badMons = append(badMons, name)
+		}
+	}
+	if len(badMons) == 0 {
+		return errors.Errorf("mon %q is the only mon, there is no quorum to restore", healthyMon)
+	}
+	sort.Strings(badMons)
+
+	// the operator needs the connection config to check the quorum, e.g. right after it restarted
+	if err := WriteConnectionConfig(c.context, c.ClusterInfo); err != nil {
+		return errors.Wrap(err, "failed to write the connection config")
+	}
+	if err := c.confirmQuorumLost(); err != nil {
+		return err
+	}
+	if err := c.confirmMonOutOfQuorum(healthyMon); err != nil {
+		return err
+	}
+
+	d, err := c.context.Clientset.AppsV1().Deployments(c.Namespace).Get(resourceName(healthyMon), metav1.GetOptions{})
+	if err != nil {
+		return errors.Wrapf(err, "failed to get the deployment of the healthy mon %q", healthyMon)
+	}
+
+	logger.Warningf("restoring the mon quorum from mon %q, removing mons %v", healthyMon, badMons)
+	for _, name := range badMons {
+		c.removeMonResources(name)
+	}
+	if err := c.saveMonConfig(); err != nil {
+		return errors.Wrap(err, "failed to save the mon config with the healthy mon only")
+	}
+
+	// the monmap is updated by an init container before the mon container starts
+	if err := addRestoreQuorumContainer(d, badMons); err != nil {
+		return err
+	}
+	d, err = c.context.Clientset.AppsV1().Deployments(c.Namespace).Update(d)
+	if err != nil {
+		return errors.Wrapf(err, "failed to update the deployment of the healthy mon %q", healthyMon)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), restoreQuorumTimeout)
+	defer cancel()
+	err = k8sutil.WaitForRollout(ctx, c.context.Clientset, d, func(p k8sutil.RolloutProgress) {
+		logger.Infof("restoring the mon quorum from mon %q: %s", healthyMon, p.String())
+	})
+	if err != nil {
+		return errors.Wrapf(err, "failed to restart the healthy mon %q with the new monmap", healthyMon)
+	}
+
+	if err := c.waitForQuorum(); err != nil {
+		return errors.Wrapf(err, "mon %q did not form a quorum after the restore", healthyMon)
+	}
+	logger.Infof("restored the mon quorum with mon %q", healthyMon)
+
+	// the monmap must not be updated again when the mon restarts
+	d, err = c.context.Clientset.AppsV1().Deployments(c.Namespace).Get(resourceName(healthyMon), metav1.GetOptions{})
+	if err != nil {
+		return errors.Wrapf(err, "failed to get the deployment of mon %q to remove the restore container", healthyMon)
+	}
+	if removeRestoreQuorumContainer(d) {
+		if err := updateDeploymentAndWait(c.context, c.ClusterInfo, d, config.MonType, healthyMon, true, false); err != nil {
+			return errors.Wrapf(err, "failed to remove the restore container from the deployment of mon %q", healthyMon)
+		}
+	}
+	return nil
+}
+
+// confirmQuorumLost returns an error unless the quorum status cannot be read on several
+// consecutive checks, a single failure can be a transient connection issue
+func (c *Cluster) confirmQuorumLost() error {
+	for i := 1; i <= restoreQuorumChecks; i++ {
+		if _, err := cephclient.GetMonQuorumStatus(c.context, c.ClusterInfo); err == nil {
+			return errors.New("refusing to restore the mon quorum since the mons are in quorum")
+		}
+		logger.Infof("mon quorum check %d/%d failed", i, restoreQuorumChecks)
+		if i < restoreQuorumChecks {
+			time.Sleep(restoreQuorumCheckInterval)
+		}
+	}
+	return nil
+}
+
+// confirmMonOutOfQuorum returns an error unless the admin socket of the mon reports it is not in
+// quorum. The admin socket answers even when the mons cannot form a quorum.
+func (c *Cluster) confirmMonOutOfQuorum(name string) error {
+	output, err := monAdminSocketCommand(c, name, "mon_status")
+	if err != nil {
+		return errors.Wrapf(err, "failed to get the status of mon %q from its admin socket", name)
+	}
+	var status monAdminSocketStatus
+	if err := json.Unmarshal([]byte(output), &status); err != nil {
+		return errors.Wrapf(err, "failed to parse the status of mon %q", name)
+	}
+	if status.State == "leader" || status.State == "peon" || len(status.Quorum) > 0 {
+		return errors.Errorf("refusing to restore the mon quorum since mon %q is in quorum (state %q)", name, status.State)
+	}
+	logger.Infof("mon %q is not in quorum (state %q)", name, status.State)
+	return nil
+}
+
+// waitForQuorum waits for the mons to form a quorum
+func (c *Cluster) waitForQuorum() error {
+	var err error
+	for i := 1; i <= restoreQuorumChecks; i++ {
+		if _, err = cephclient.GetMonQuorumStatus(c.context, c.ClusterInfo); err == nil {
+			return nil
+		}
+		if i < restoreQuorumChecks {
+			time.Sleep(restoreQuorumCheckInterval)
+		}
+	}
+	return err
+}
+
+// runMonAdminSocketCommand runs a command on the admin socket of a mon in its running pod
+func runMonAdminSocketCommand(c *Cluster, name, command string) (string, error) {
+	selector := fmt.Sprintf("%s=%s,%s=%s", k8sutil.AppAttr, AppName, config.MonType, name)
+	pods, err := c.context.Clientset.CoreV1().Pods(c.Namespace).List(metav1.ListOptions{LabelSelector: selector})
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to list the pods of mon %q", name)
+	}
+	for _, pod := range pods.Items {
+		if pod.Status.Phase != v1.PodRunning {
+			continue
+		}
+		// run with a clean env like the liveness probe to avoid the CEPH_ARGS of the container
+		socketCommand := fmt.Sprintf("ceph --admin-daemon %s %s", controller.DaemonAdminSocketPath(config.MonType, name), command)
+		return k8sutil.ExecInPod(c.context.Clientset, c.context.KubeConfig, c.Namespace, pod.Name, "mon", "env", "-i", "sh", "-c", socketCommand)
+	}
+	return "", errors.Errorf("no running pod for mon %q", name)
+}
+
+// addRestoreQuorumContainer adds an init container to the mon deployment which removes the given
+// mons from the monmap of the mon. The mons that are not in the monmap are skipped, so the
+// container can safely run again if the mon restarts before the deployment is updated.
+func addRestoreQuorumContainer(d *apps.Deployment, badMons []string) error {
+	var mon *v1.Container
+	for i := range d.Spec.Template.Spec.Containers {
+		if d.Spec.Template.Spec.Containers[i].Name == "mon" {
+			mon = &d.Spec.Template.Spec.Containers[i]
+		}
+	}
+	if mon == nil {
+		return errors.Errorf("failed to find the mon container in deployment %q", d.Name)
+	}
+
+	// the container runs with the same args as the mon, so the args still reference the env vars
+	// expanded by kubernetes
+	restore := *mon.DeepCopy()
+	restore.Name = restoreQuorumContainerName
+	restore.Command = []string{"/bin/bash", "-c"}
+	restore.Args = append([]string{restoreQuorumScript(badMons), restoreQuorumContainerName}, mon.Args...)
+	restore.LivenessProbe = nil
+	restore.ReadinessProbe = nil
+
+	removeRestoreQuorumContainer(d)
+	d.Spec.Template.Spec.InitContainers = append(d.Spec.Template.Spec.InitContainers, restore)
+	return nil
+}
+
+// removeRestoreQuorumContainer removes the init container updating the monmap from the mon
+// deployment, and returns whether it was found
+func removeRestoreQuorumContainer(d *apps.Deployment) bool {
+	found := false
+	initContainers := []v1.Container{}
+	for _, c := range d.Spec.Template.Spec.InitContainers {
+		if c.Name == restoreQuorumContainerName {
+			found = true
+			continue
+		}
+		initContainers = append(initContainers, c)
+	}
+	d.Spec.Template.Spec.InitContainers = initContainers
+	return found
+}
+
+// restoreQuorumScript returns the script removing the given mons from the monmap. The ceph-mon
+// args are passed as the positional parameters of the script.
+func restoreQuorumScript(badMons []string) string {
+	return fmt.Sprintf(`set -xe
+ceph-mon "$@" --extract-monmap=%[1]s
+monmaptool --print %[1]s
+for mon in %[2]s; do
+  if monmaptool --print %[1]s | grep -q " mon\.${mon}$"; then
+    monmaptool %[1]s --rm "${mon}"
+  fi
+done
+ceph-mon "$@" --inject-monmap=%[1]s
+`, restoreQuorumMonmapPath, strings.Join(badMons, " "))
+}
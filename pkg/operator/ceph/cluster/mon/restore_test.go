@@ -0,0 +1,133 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mon
+
+import (
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/rook/rook/pkg/clusterd"
+	clienttest "github.com/rook/rook/pkg/daemon/ceph/client/test"
+	exectest "github.com/rook/rook/pkg/util/exec/test"
+	"github.com/stretchr/testify/assert"
+	apps "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+)
+
+func TestAddRestoreQuorumContainer(t *testing.T) {
+	d := &apps.Deployment{}
+	d.Spec.Template.Spec.InitContainers = []v1.Container{{Name: "chown-container-data-dir"}}
+	d.Spec.Template.Spec.Containers = []v1.Container{{
+		Name:          "mon",
+		Command:       []string{"ceph-mon"},
+		Args:          []string{"--id=b", "--mon-host=$(ROOK_CEPH_MON_HOST)"},
+		LivenessProbe: &v1.Probe{},
+	}}
+
+	err := addRestoreQuorumContainer(d, []string{"a", "c"})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(d.Spec.Template.Spec.InitContainers))
+	restore := d.Spec.Template.Spec.InitContainers[1]
+	assert.Equal(t, restoreQuorumContainerName, restore.Name)
+	assert.Equal(t, []string{"/bin/bash", "-c"}, restore.Command)
+	assert.Equal(t, restoreQuorumScript([]string{"a", "c"}), restore.Args[0])
+	assert.Equal(t, []string{"--id=b", "--mon-host=$(ROOK_CEPH_MON_HOST)"}, restore.Args[2:])
+	assert.Nil(t, restore.LivenessProbe)
+
+	// the mon container is not modified
+	assert.Equal(t, []string{"ceph-mon"}, d.Spec.Template.Spec.Containers[0].Command)
+	assert.NotNil(t, d.Spec.Template.Spec.Containers[0].LivenessProbe)
+
+	// the container is replaced when added again
+	err = addRestoreQuorumContainer(d, []string{"a"})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(d.Spec.Template.Spec.InitContainers))
+	assert.Equal(t, restoreQuorumScript([]string{"a"}), d.Spec.Template.Spec.InitContainers[1].Args[0])
+
+	// the container is removed once the quorum is restored
+	assert.True(t, removeRestoreQuorumContainer(d))
+	assert.Equal(t, 1, len(d.Spec.Template.Spec.InitContainers))
+	assert.Equal(t, "chown-container-data-dir", d.Spec.Template.Spec.InitContainers[0].Name)
+	assert.False(t, removeRestoreQuorumContainer(d))
+
+	// the mon container is required
+	d.Spec.Template.Spec.Containers[0].Name = "foo"
+	assert.Error(t, addRestoreQuorumContainer(d, []string{"a"}))
+}
+
+func TestConfirmQuorumLost(t *testing.T) {
+	restoreQuorumCheckInterval = 0
+	checks := 0
+	inQuorumAtCheck := 0
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutputFile: func(command string, outFileArg string, args ...string) (string, error) {
+			if args[0] == "quorum_status" {
+				checks++
+				if checks == inQuorumAtCheck {
+					return clienttest.MonInQuorumResponse(), nil
+				}
+			}
+			return "", errors.New("timed out")
+		},
+	}
+	c := &Cluster{context: &clusterd.Context{Executor: executor}, ClusterInfo: clienttest.CreateTestClusterInfo(3)}
+
+	// the quorum is lost on every check
+	assert.NoError(t, c.confirmQuorumLost())
+	assert.Equal(t, restoreQuorumChecks, checks)
+
+	// a single failed check is not enough
+	checks = 0
+	inQuorumAtCheck = restoreQuorumChecks
+	assert.Error(t, c.confirmQuorumLost())
+	assert.Equal(t, restoreQuorumChecks, checks)
+}
+
+func TestConfirmMonOutOfQuorum(t *testing.T) {
+	status := ""
+	monAdminSocketCommand = func(c *Cluster, name, command string) (string, error) {
+		assert.Equal(t, "b", name)
+		assert.Equal(t, "mon_status", command)
+		return status, nil
+	}
+	defer func() { monAdminSocketCommand = runMonAdminSocketCommand }()
+	c := &Cluster{}
+
+	status = `{"name":"b","state":"probing","quorum":[]}`
+	assert.NoError(t, c.confirmMonOutOfQuorum("b"))
+	status = `{"name":"b","state":"electing","quorum":[]}`
+	assert.NoError(t, c.confirmMonOutOfQuorum("b"))
+
+	// the mon is in quorum
+	status = `{"name":"b","state":"leader","quorum":[0,1]}`
+	assert.Error(t, c.confirmMonOutOfQuorum("b"))
+	status = `{"name":"b","state":"peon","quorum":[0,1]}`
+	assert.Error(t, c.confirmMonOutOfQuorum("b"))
+
+	// the status is unknown
+	status = "not json"
+	assert.Error(t, c.confirmMonOutOfQuorum("b"))
+}
+
+func TestRestoreQuorumScript(t *testing.T) {
+	script := restoreQuorumScript([]string{"a", "c"})
+	assert.Contains(t, script, `ceph-mon "$@" --extract-monmap=/tmp/monmap`)
+	assert.Contains(t, script, "for mon in a c; do")
+	assert.Contains(t, script, `ceph-mon "$@" --inject-monmap=/tmp/monmap`)
+	// kubernetes would expand $(VAR) references in the script
+	assert.NotContains(t, script, "$(")
+}
@@ -0,0 +1,114 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mon
+
+import (
+	"github.com/pkg/errors"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	rookv1 "github.com/rook/rook/pkg/apis/rook.io/v1"
+	"github.com/rook/rook/pkg/operator/k8sutil"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// monPlacement returns the placement of the mon pods from the CRD. The topology spread constraints
+// that don't have a label selector are given one selecting the mon pods, so the mons (and their
+// canaries) are spread without repeating the mon labels in the cluster CR.
+func (c *Cluster) monPlacement() rookv1.Placement {
+	p := cephv1.GetMonPlacement(c.spec.Placement)
+	if len(p.TopologySpreadConstraints) == 0 {
+		return p
+	}
+
+	// copy the constraints to avoid modifying the cluster spec
+	constraints := make([]v1.TopologySpreadConstraint, len(p.TopologySpreadConstraints))
+	for i, constraint := range p.TopologySpreadConstraints {
+		constraints[i] = *constraint.DeepCopy()
+		if constraints[i].LabelSelector == nil {
+			constraints[i].LabelSelector = &metav1.LabelSelector{
+				MatchLabels: map[string]string{k8sutil.AppAttr: AppName},
+			}
+		}
+	}
+	p.TopologySpreadConstraints = constraints
+	return p
+}
+
+// validateMonTopologySpread checks the number of topology domains (e.g. zones or racks) the mons
+// can be spread over against the mon count. An error is returned if the mons could never be
+// scheduled and a warning is logged if the loss of a single domain would break the quorum.
+func (c *Cluster) validateMonTopologySpread() error {
+	p := c.monPlacement()
+	if len(p.TopologySpreadConstraints) == 0 {
+		return nil
+	}
+
+	nodes, err := c.context.Clientset.CoreV1().Nodes().List(metav1.ListOptions{})
+	if err != nil {
+		return errors.Wrap(err, "failed to list nodes")
+	}
+
+	for _, constraint := range p.TopologySpreadConstraints {
+		domains := monTopologyDomains(nodes.Items, p, constraint.TopologyKey)
+		if len(domains) == 0 {
+			if constraint.WhenUnsatisfiable == v1.DoNotSchedule {
+				return errors.Errorf("none of the nodes available to the mons has the label %q of the mon topology spread constraint", constraint.TopologyKey)
+			}
+			logger.Warningf("none of the nodes available to the mons has the label %q of the mon topology spread constraint", constraint.TopologyKey)
+			continue
+		}
+
+		if !quorumSurvivesDomainLoss(c.spec.Mon.Count, len(domains)) {
+			logger.Warningf("the %d mons can only be spread over %d %q domain(s). the loss of a single domain would break the mon quorum",
+				c.spec.Mon.Count, len(domains), constraint.TopologyKey)
+		} else {
+			logger.Infof("the %d mons will be spread over %d %q domains", c.spec.Mon.Count, len(domains), constraint.TopologyKey)
+		}
+	}
+	return nil
+}
+
+// monTopologyDomains returns the values of the topology key of the nodes the mons can run on
+func monTopologyDomains(nodes []v1.Node, p rookv1.Placement, topologyKey string) map[string]struct{} {
+	domains := map[string]struct{}{}
+	for _, node := range nodes {
+		value, ok := node.Labels[topologyKey]
+		if !ok {
+			continue
+		}
+		valid, err := k8sutil.ValidNode(node, p)
+		if err != nil {
+			logger.Warningf("failed to validate node %q for the mons. %v", node.Name, err)
+			continue
+		}
+		if valid {
+			domains[value] = struct{}{}
+		}
+	}
+	return domains
+}
+
+// quorumSurvivesDomainLoss returns whether a majority of the mons remains when the domain with
+// the most mons is lost, given that the mons are spread evenly over the domains
+func quorumSurvivesDomainLoss(monCount, domainCount int) bool {
+	if monCount <= 1 || domainCount <= 0 {
+		// a single mon never survives the loss of its domain, nothing more to validate
+		return true
+	}
+	monsPerDomain := (monCount + domainCount - 1) / domainCount
+	return monCount-monsPerDomain > monCount/2
+}
@@ -0,0 +1,118 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mon
+
+import (
+	"testing"
+
+	rookv1 "github.com/rook/rook/pkg/apis/rook.io/v1"
+	"github.com/rook/rook/pkg/clusterd"
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func newTopologyTestNode(name, zone string) *v1.Node {
+	node := &v1.Node{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   name,
+			Labels: map[string]string{v1.LabelHostname: name},
+		},
+		Status: v1.NodeStatus{
+			Conditions: []v1.NodeCondition{{Type: v1.NodeReady, Status: v1.ConditionTrue}},
+		},
+	}
+	if zone != "" {
+		node.Labels[v1.LabelZoneFailureDomainStable] = zone
+	}
+	return node
+}
+
+func TestMonPlacementTopologySpread(t *testing.T) {
+	c := newCluster(&clusterd.Context{}, "ns", false, v1.ResourceRequirements{})
+	p := c.monPlacement()
+	assert.Nil(t, p.TopologySpreadConstraints)
+
+	// the label selector of the mons is added when not set
+	custom := &metav1.LabelSelector{MatchLabels: map[string]string{"foo": "bar"}}
+	c.spec.Placement = rookv1.PlacementSpec{
+		rookv1.KeyMon: rookv1.Placement{
+			TopologySpreadConstraints: []v1.TopologySpreadConstraint{
+				{MaxSkew: 1, TopologyKey: v1.LabelZoneFailureDomainStable, WhenUnsatisfiable: v1.DoNotSchedule},
+				{MaxSkew: 1, TopologyKey: v1.LabelHostname, WhenUnsatisfiable: v1.ScheduleAnyway, LabelSelector: custom},
+			},
+		},
+	}
+	p = c.monPlacement()
+	assert.Equal(t, 2, len(p.TopologySpreadConstraints))
+	assert.Equal(t, map[string]string{"app": AppName}, p.TopologySpreadConstraints[0].LabelSelector.MatchLabels)
+	assert.Equal(t, custom, p.TopologySpreadConstraints[1].LabelSelector)
+
+	// the cluster spec is not modified
+	assert.Nil(t, c.spec.Placement[rookv1.KeyMon].TopologySpreadConstraints[0].LabelSelector)
+}
+
+func TestValidateMonTopologySpread(t *testing.T) {
+	clientset := fake.NewSimpleClientset(
+		newTopologyTestNode("node1", "a"),
+		newTopologyTestNode("node2", "b"),
+		newTopologyTestNode("node3", "b"),
+		newTopologyTestNode("node4", ""),
+	)
+	c := newCluster(&clusterd.Context{Clientset: clientset}, "ns", false, v1.ResourceRequirements{})
+
+	// no constraint to validate
+	assert.NoError(t, c.validateMonTopologySpread())
+
+	constraint := v1.TopologySpreadConstraint{MaxSkew: 1, TopologyKey: v1.LabelZoneFailureDomainStable, WhenUnsatisfiable: v1.DoNotSchedule}
+	c.spec.Placement = rookv1.PlacementSpec{
+		rookv1.KeyMon: rookv1.Placement{TopologySpreadConstraints: []v1.TopologySpreadConstraint{constraint}},
+	}
+	// two zones are only a warning
+	assert.NoError(t, c.validateMonTopologySpread())
+	p := c.monPlacement()
+	assert.Equal(t, 2, len(monTopologyDomains(mustListNodes(t, clientset), p, v1.LabelZoneFailureDomainStable)))
+
+	// no node has the topology key
+	constraint.TopologyKey = "topology.rook.io/rack"
+	c.spec.Placement[rookv1.KeyMon] = rookv1.Placement{TopologySpreadConstraints: []v1.TopologySpreadConstraint{constraint}}
+	assert.Error(t, c.validateMonTopologySpread())
+
+	// the pods can still be scheduled when the constraint is not required
+	constraint.WhenUnsatisfiable = v1.ScheduleAnyway
+	c.spec.Placement[rookv1.KeyMon] = rookv1.Placement{TopologySpreadConstraints: []v1.TopologySpreadConstraint{constraint}}
+	assert.NoError(t, c.validateMonTopologySpread())
+}
+
+func TestQuorumSurvivesDomainLoss(t *testing.T) {
+	assert.True(t, quorumSurvivesDomainLoss(1, 1))
+	assert.False(t, quorumSurvivesDomainLoss(3, 1))
+	assert.False(t, quorumSurvivesDomainLoss(3, 2))
+	assert.True(t, quorumSurvivesDomainLoss(3, 3))
+	assert.True(t, quorumSurvivesDomainLoss(3, 5))
+	assert.False(t, quorumSurvivesDomainLoss(5, 2))
+	assert.True(t, quorumSurvivesDomainLoss(5, 3))
+	assert.False(t, quorumSurvivesDomainLoss(4, 2))
+	assert.True(t, quorumSurvivesDomainLoss(4, 4))
+}
+
+func mustListNodes(t *testing.T, clientset *fake.Clientset) []v1.Node {
+	nodes, err := clientset.CoreV1().Nodes().List(metav1.ListOptions{})
+	assert.NoError(t, err)
+	return nodes.Items
+}
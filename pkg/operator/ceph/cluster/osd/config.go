@@ -48,6 +48,8 @@ key = %s
 	dmCryptKeySize                 = 128
 	// #nosec G101 since this is not leaking any hardcoded credentials, it's just the prefix of the secret name
 	osdEncryptionSecretNamePrefix = "rook-ceph-osd-encryption-key"
+	// the label of the osd encryption key secrets with the name of the pvc of the osd
+	encryptionSecretPVCLabelKey = "pvc_name"
 )
 
 func (c *Cluster) generateKeyring(osdID int) (string, error) {
@@ -111,6 +113,50 @@ func osdOnSDNFlag(network cephv1.NetworkSpec) []string {
 	return args
 }
 
+// osdMemoryTargetFlag returns the flag setting the share of the memory limit of the osd pod given
+// to the osd_memory_target of the osd. A share of the limit is left for the memory the osd uses
+// outside of its caches, which is bigger for the osds on flash devices since they handle more
+// requests. Ceph only uses the ratio to compute the default of the osd_memory_target from the
+// POD_MEMORY_LIMIT env var, so a memory target set in the ceph config still applies. No flag is
+// returned when the memory target is not autotuned, the device class of the osd is not known or the
+// pod has no memory limit.
+func (c *Cluster) osdMemoryTargetFlag(osdProps osdProperties) []string {
+	if !c.spec.Storage.AutotuneMemoryTarget {
+		return []string{}
+	}
+	if !c.clusterInfo.CephVersion.IsAtLeastOctopus() {
+		logger.Infof("not autotuning the memory target of osds on %q, ceph %s does not support it", osdProps.crushHostname, c.clusterInfo.CephVersion.String())
+		return []string{}
+	}
+	limit := osdProps.resources.Limits.Memory()
+	if limit.IsZero() {
+		logger.Infof("not autotuning the memory target of osds on %q without a memory limit", osdProps.crushHostname)
+		return []string{}
+	}
+
+	deviceClass := osdProps.crushDeviceClass
+	if deviceClass == "" {
+		deviceClass = osdProps.storeConfig.DeviceClass
+	}
+	var ratio float64
+	switch {
+	case deviceClass == "hdd" || osdProps.tuneSlowDeviceClass:
+		ratio = osdMemoryTargetHDDRatio
+	case deviceClass == "ssd" || deviceClass == "nvme":
+		ratio = osdMemoryTargetFlashRatio
+	default:
+		// the ratio of ceph applies
+		logger.Infof("not autotuning the memory target of osds on %q without a known device class", osdProps.crushHostname)
+		return []string{}
+	}
+
+	if float64(limit.Value())*ratio < osdMemoryTargetMin {
+		logger.Warningf("not autotuning the memory target of osds on %q, the memory limit %s is too low", osdProps.crushHostname, limit.String())
+		return []string{}
+	}
+	return []string{opconfig.NewFlag("osd-memory-target-cgroup-limit-ratio", strconv.FormatFloat(ratio, 'f', -1, 64))}
+}
+
 func (c *Cluster) skipVolumeForDirectory(path string) bool {
 	// If attempting to add a directory at /var/lib/rook, we need to skip the volume and volume mount
 	// since the dataDirHostPath is always mounting at /var/lib/rook
@@ -144,7 +190,7 @@ func generateOSDEncryptedKeySecret(pvcName, namespace, key string) *v1.Secret {
 			Name:      generateOSDEncryptionSecretName(pvcName),
 			Namespace: namespace,
 			Labels: map[string]string{
-				"pvc_name": pvcName,
+				encryptionSecretPVCLabelKey: pvcName,
 			},
 		},
 		StringData: map[string]string{
@@ -37,14 +37,17 @@ func GetConfigStoreName(nodeName string) string {
 }
 
 const (
-	StoreTypeKey       = "storeType"
-	WalSizeMBKey       = "walSizeMB"
-	DatabaseSizeMBKey  = "databaseSizeMB"
-	JournalSizeMBKey   = "journalSizeMB"
-	OSDsPerDeviceKey   = "osdsPerDevice"
-	EncryptedDeviceKey = "encryptedDevice"
-	MetadataDeviceKey  = "metadataDevice"
-	DeviceClassKey     = "deviceClass"
+	StoreTypeKey            = "storeType"
+	WalSizeMBKey            = "walSizeMB"
+	DatabaseSizeMBKey       = "databaseSizeMB"
+	JournalSizeMBKey        = "journalSizeMB"
+	OSDsPerDeviceKey        = "osdsPerDevice"
+	EncryptedDeviceKey      = "encryptedDevice"
+	MetadataDeviceKey       = "metadataDevice"
+	WalDeviceKey            = "walDevice"
+	DeviceClassKey          = "deviceClass"
+	SkipUnhealthyDevicesKey = "skipUnhealthyDevices"
+	MaxMediaWearoutKey      = "maxMediaWearout"
 )
 
 // StoreConfig represents the configuration of an OSD on a device.
@@ -55,7 +58,12 @@ type StoreConfig struct {
 	OSDsPerDevice   int    `json:"osdsPerDevice,omitempty"`
 	EncryptedDevice bool   `json:"encryptedDevice,omitempty"`
 	MetadataDevice  string `json:"metadataDevice,omitempty"`
+	WalDevice       string `json:"walDevice,omitempty"`
 	DeviceClass     string `json:"deviceClass,omitempty"`
+	// The devices whose SMART health self-assessment failed are not provisioned
+	SkipUnhealthyDevices bool `json:"skipUnhealthyDevices,omitempty"`
+	// The devices whose media wearout percentage reached this value are not provisioned, 0 for no limit
+	MaxMediaWearout int `json:"maxMediaWearout,omitempty"`
 }
 
 // NewStoreConfig returns a StoreConfig with proper defaults set.
@@ -85,8 +93,14 @@ func ToStoreConfig(config map[string]string) StoreConfig {
 			storeConfig.EncryptedDevice = (v == "true")
 		case MetadataDeviceKey:
 			storeConfig.MetadataDevice = v
+		case WalDeviceKey:
+			storeConfig.WalDevice = v
 		case DeviceClassKey:
 			storeConfig.DeviceClass = v
+		case SkipUnhealthyDevicesKey:
+			storeConfig.SkipUnhealthyDevices = (v == "true")
+		case MaxMediaWearoutKey:
+			storeConfig.MaxMediaWearout = convertToIntIgnoreErr(v)
 		}
 	}
 
@@ -104,6 +118,10 @@ func MetadataDevice(config map[string]string) string {
 	return ""
 }
 
+func WalDevice(config map[string]string) string {
+	return config[WalDeviceKey]
+}
+
 func convertToIntIgnoreErr(raw string) int {
 	val, err := strconv.Atoi(raw)
 	if err != nil {
@@ -26,6 +26,8 @@ import (
 	cephver "github.com/rook/rook/pkg/operator/ceph/version"
 	"github.com/rook/rook/pkg/operator/k8sutil"
 	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 )
 
 func TestOsdOnSDNFlag(t *testing.T) {
@@ -39,6 +41,42 @@ func TestOsdOnSDNFlag(t *testing.T) {
 	assert.Empty(t, args)
 }
 
+func TestOSDMemoryTargetFlag(t *testing.T) {
+	c := &Cluster{clusterInfo: &cephclient.ClusterInfo{CephVersion: cephver.Octopus}}
+	osdProps := osdProperties{
+		crushHostname: "node1",
+		resources: v1.ResourceRequirements{
+			Limits: v1.ResourceList{v1.ResourceMemory: resource.MustParse("10Gi")},
+		},
+	}
+
+	// the memory target is not autotuned by default
+	assert.Empty(t, c.osdMemoryTargetFlag(osdProps))
+
+	// the osd device class is not known, the ratio of ceph applies
+	c.spec.Storage.AutotuneMemoryTarget = true
+	assert.Empty(t, c.osdMemoryTargetFlag(osdProps))
+
+	osdProps.storeConfig.DeviceClass = "hdd"
+	assert.Equal(t, []string{"--osd-memory-target-cgroup-limit-ratio=0.8"}, c.osdMemoryTargetFlag(osdProps))
+
+	osdProps.crushDeviceClass = "nvme"
+	assert.Equal(t, []string{"--osd-memory-target-cgroup-limit-ratio=0.7"}, c.osdMemoryTargetFlag(osdProps))
+
+	// the ratio is not supported before octopus
+	c.clusterInfo.CephVersion = cephver.Nautilus
+	assert.Empty(t, c.osdMemoryTargetFlag(osdProps))
+	c.clusterInfo.CephVersion = cephver.Octopus
+
+	// the memory limit is too low
+	osdProps.resources.Limits[v1.ResourceMemory] = resource.MustParse("1Gi")
+	assert.Empty(t, c.osdMemoryTargetFlag(osdProps))
+
+	// no memory limit
+	osdProps.resources = v1.ResourceRequirements{}
+	assert.Empty(t, c.osdMemoryTargetFlag(osdProps))
+}
+
 func TestEncryptionKeyPath(t *testing.T) {
 	assert.Equal(t, "/etc/ceph/luks_key", encryptionKeyPath())
 }
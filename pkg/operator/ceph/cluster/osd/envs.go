@@ -43,6 +43,10 @@ const (
 	cvModeVarName                    = "ROOK_CV_MODE"
 	lvBackedPVVarName                = "ROOK_LV_BACKED_PV"
 	CrushDeviceClassVarName          = "ROOK_OSD_CRUSH_DEVICE_CLASS"
+
+	// the devices filtered out on their health by the osd prepare job
+	skipUnhealthyDevicesEnvVarName = "ROOK_SKIP_UNHEALTHY_DEVICES"
+	maxMediaWearoutEnvVarName      = "ROOK_MAX_MEDIA_WEAROUT"
 )
 
 func (c *Cluster) getConfigEnvVars(osdProps osdProperties, dataDir string) []v1.EnvVar {
@@ -94,6 +98,14 @@ func (c *Cluster) getConfigEnvVars(osdProps osdProperties, dataDir string) []v1.
 		envVars = append(envVars, v1.EnvVar{Name: EncryptedDeviceEnvVarName, Value: "true"})
 	}
 
+	if osdProps.storeConfig.SkipUnhealthyDevices {
+		envVars = append(envVars, v1.EnvVar{Name: skipUnhealthyDevicesEnvVarName, Value: "true"})
+	}
+
+	if osdProps.storeConfig.MaxMediaWearout != 0 {
+		envVars = append(envVars, v1.EnvVar{Name: maxMediaWearoutEnvVarName, Value: strconv.Itoa(osdProps.storeConfig.MaxMediaWearout)})
+	}
+
 	return envVars
 }
 
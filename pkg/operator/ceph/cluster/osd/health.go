@@ -94,7 +94,18 @@ func (m *OSDHealthMonitor) Update(removeOSDsIfOUTAndSafeToRemove bool) {
 
 // checkOSDHealth takes action when needed if the OSDs are not healthy
 func (m *OSDHealthMonitor) checkOSDHealth() {
-	err := m.checkOSDDump()
+	// OSDs must not be removed or restarted while an admin performs a manual maintenance
+	paused, err := opcontroller.IsClusterReconcilePaused(m.context.Client, m.clusterInfo.Namespace)
+	if err != nil {
+		logger.Debugf("failed to check whether the cluster reconcile is paused. %v", err)
+		return
+	}
+	if paused {
+		logger.Debugf("skipping osd health check since the reconcile of the cluster in namespace %q is paused", m.clusterInfo.Namespace)
+		return
+	}
+
+	err = m.checkOSDDump()
 	if err != nil {
 		logger.Debugf("failed to check OSD Dump. %v", err)
 	}
@@ -102,6 +113,14 @@ func (m *OSDHealthMonitor) checkOSDHealth() {
 	if err != nil {
 		logger.Debugf("failed to check device classes. %v", err)
 	}
+	err = m.checkOSDResize()
+	if err != nil {
+		logger.Debugf("failed to check the osd sizes. %v", err)
+	}
+	err = m.checkKeyRotation()
+	if err != nil {
+		logger.Debugf("failed to check the osd key rotations. %v", err)
+	}
 }
 
 func (m *OSDHealthMonitor) checkDeviceClasses() error {
@@ -183,6 +202,12 @@ func (m *OSDHealthMonitor) removeOSDDeploymentIfSafeToDestroy(outOSDid int) erro
 			currentTime := time.Now().UTC()
 			if podDeletionTimeStamp.Before(currentTime) {
 				logger.Infof("osd.%d is 'safe-to-destroy'. removing the osd deployment.", outOSDid)
+				// the other osds carved out of the pvc keep running, the osd is not started again
+				if pvcName, ok := dp.Items[0].Labels[OSDOverPVCLabelKey]; ok {
+					if err := RemoveOSDFromPVC(m.context, m.clusterInfo.Namespace, pvcName, outOSDid); err != nil {
+						return errors.Wrapf(err, "failed to remove osd.%d from pvc %q", outOSDid, pvcName)
+					}
+				}
 				if err := k8sutil.DeleteDeployment(m.context.Clientset, dp.Items[0].Namespace, dp.Items[0].Name); err != nil {
 					return errors.Wrapf(err, "failed to delete osd deployment %s", dp.Items[0].Name)
 				}
@@ -226,6 +251,10 @@ func (m *OSDHealthMonitor) updateCephStatus(devices []string) {
 		logger.Errorf("failed to retrieve ceph cluster %q to update ceph Storage. %v", m.clusterInfo.NamespacedName().Name, err)
 		return
 	}
+	// the key rotation status is updated by its own check
+	if cephCluster.Status.CephStorage != nil {
+		cephClusterStorage.KeyRotation = cephCluster.Status.CephStorage.KeyRotation
+	}
 	if !reflect.DeepEqual(cephCluster.Status.CephStorage, &cephClusterStorage) {
 		cephCluster.Status.CephStorage = &cephClusterStorage
 		if err := opcontroller.UpdateStatus(m.context.Client, cephCluster); err != nil {
@@ -0,0 +1,313 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package osd
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	rookv1 "github.com/rook/rook/pkg/apis/rook.io/v1"
+	"github.com/rook/rook/pkg/daemon/ceph/client"
+	"github.com/rook/rook/pkg/operator/ceph/controller"
+	"github.com/rook/rook/pkg/operator/k8sutil"
+	batch "k8s.io/api/batch/v1"
+	batchv1beta1 "k8s.io/api/batch/v1beta1"
+	v1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// KeyRotationStatusAnnotation is the annotation of the encryption key secret with the status of the last rotation
+	KeyRotationStatusAnnotation = "ceph.rook.io/key-rotation-status"
+	// KeyRotationTimeAnnotation is the annotation of the encryption key secret with the time of the last rotation
+	KeyRotationTimeAnnotation = "ceph.rook.io/key-rotation-time"
+	// KeyRotationSucceeded is the status of a rotation completed by the rotation job
+	KeyRotationSucceeded = "Succeeded"
+	// KeyRotationFailed is the status of a rotation that failed and is resumed the next time
+	KeyRotationFailed = "Failed"
+	// KeyRotationRestarting is the status of an osd restarted, or waiting to be restarted, after its
+	// key was rotated
+	KeyRotationRestarting = "Restarting"
+	// KeyRotationVerified is the status of an osd that opened its blocks with its new key
+	KeyRotationVerified = "Verified"
+
+	keyRotationAppName         = "rook-ceph-osd-key-rotation"
+	keyRotationNameFmt         = "osd-key-rotation-%s"
+	defaultKeyRotationSchedule = "@weekly"
+	// the names of the jobs created by a cron job get a suffix of 11 characters
+	cronJobNameMaxLength = 52
+)
+
+// reconcileKeyRotation creates a cron job rotating the encryption key of each encrypted osd on pvc
+// when the key rotation is enabled, and deletes the cron jobs of the osds whose key is not rotated
+// anymore
+func (c *Cluster) reconcileKeyRotation() error {
+	cronJobs := c.context.Clientset.BatchV1beta1().CronJobs(c.clusterInfo.Namespace)
+	desired := map[string]bool{}
+	if c.spec.Security.KeyRotation.Enabled {
+		for _, volume := range c.ValidStorage.VolumeSources {
+			if !volume.Encrypted {
+				continue
+			}
+			if _, ok := volume.PVCSources[bluestorePVCData]; !ok {
+				continue
+			}
+			cronJob := c.makeKeyRotationCronJob(volume)
+			desired[cronJob.Name] = true
+			if _, err := cronJobs.Create(cronJob); err != nil {
+				if !kerrors.IsAlreadyExists(err) {
+					return errors.Wrapf(err, "failed to create key rotation cron job %q", cronJob.Name)
+				}
+				if _, err := cronJobs.Update(cronJob); err != nil {
+					return errors.Wrapf(err, "failed to update key rotation cron job %q", cronJob.Name)
+				}
+			}
+			logger.Debugf("encryption key of the osd on pvc %q is rotated with schedule %q", volume.PVCSources[bluestorePVCData].ClaimName, cronJob.Spec.Schedule)
+		}
+	}
+
+	selector := fmt.Sprintf("%s=%s", k8sutil.AppAttr, keyRotationAppName)
+	existing, err := cronJobs.List(metav1.ListOptions{LabelSelector: selector})
+	if err != nil {
+		return errors.Wrap(err, "failed to list key rotation cron jobs")
+	}
+	for _, cronJob := range existing.Items {
+		if desired[cronJob.Name] {
+			continue
+		}
+		logger.Infof("deleting key rotation cron job %q", cronJob.Name)
+		propagation := metav1.DeletePropagationBackground
+		if err := cronJobs.Delete(cronJob.Name, &metav1.DeleteOptions{PropagationPolicy: &propagation}); err != nil && !kerrors.IsNotFound(err) {
+			return errors.Wrapf(err, "failed to delete key rotation cron job %q", cronJob.Name)
+		}
+	}
+	return nil
+}
+
+// makeKeyRotationCronJob returns the cron job rotating the key of the encrypted blocks of an osd.
+// The job runs on the node of the osd since the blocks are attached to that node.
+func (c *Cluster) makeKeyRotationCronJob(volume rookv1.VolumeSource) *batchv1beta1.CronJob {
+	pvcName := volume.PVCSources[bluestorePVCData].ClaimName
+	volumes := []v1.Volume{}
+	devices := []v1.VolumeDevice{}
+	blocks := []string{}
+	for _, name := range []string{bluestorePVCData, bluestorePVCMetadata, bluestorePVCWal} {
+		source, ok := volume.PVCSources[name]
+		if !ok {
+			continue
+		}
+		claim := source
+		volumes = append(volumes, v1.Volume{Name: claim.ClaimName, VolumeSource: v1.VolumeSource{PersistentVolumeClaim: &claim}})
+		devices = append(devices, v1.VolumeDevice{Name: claim.ClaimName, DevicePath: fmt.Sprintf("/%s", claim.ClaimName)})
+		blocks = append(blocks, fmt.Sprintf("/%s", claim.ClaimName))
+	}
+
+	podSpec := v1.PodSpec{
+		ServiceAccountName: serviceAccountName,
+		Containers: []v1.Container{
+			{
+				Name:  "rotate-key",
+				Image: k8sutil.MakeRookImage(c.rookVersion),
+				Args: []string{"ceph", "osd", "rotate-key",
+					"--secret-name", generateOSDEncryptionSecretName(pvcName),
+					"--encrypted-blocks", strings.Join(blocks, ","),
+				},
+				Env:             []v1.EnvVar{k8sutil.NamespaceEnvVar()},
+				VolumeDevices:   devices,
+				SecurityContext: PrivilegedContext(),
+			},
+		},
+		RestartPolicy:     v1.RestartPolicyOnFailure,
+		Volumes:           volumes,
+		Affinity:          &v1.Affinity{},
+		Tolerations:       volume.Placement.Tolerations,
+		PriorityClassName: cephv1.GetOSDPriorityClassName(c.spec.PriorityClassNames),
+	}
+	addPVCPodAffinity(&podSpec, pvcName)
+
+	labels := controller.AppLabels(keyRotationAppName, c.clusterInfo.Namespace)
+	labels[OSDOverPVCLabelKey] = pvcName
+	schedule := c.spec.Security.KeyRotation.Schedule
+	if schedule == "" {
+		schedule = defaultKeyRotationSchedule
+	}
+	cronJob := &batchv1beta1.CronJob{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      keyRotationCronJobName(pvcName),
+			Namespace: c.clusterInfo.Namespace,
+			Labels:    labels,
+		},
+		Spec: batchv1beta1.CronJobSpec{
+			Schedule:          schedule,
+			ConcurrencyPolicy: batchv1beta1.ForbidConcurrent,
+			JobTemplate: batchv1beta1.JobTemplateSpec{
+				Spec: batch.JobSpec{
+					Template: v1.PodTemplateSpec{
+						ObjectMeta: metav1.ObjectMeta{Labels: labels},
+						Spec:       podSpec,
+					},
+				},
+			},
+		},
+	}
+	k8sutil.SetOwnerRef(&cronJob.ObjectMeta, &c.clusterInfo.OwnerRef)
+	return cronJob
+}
+
+func keyRotationCronJobName(pvcName string) string {
+	name := fmt.Sprintf(keyRotationNameFmt, pvcName)
+	if len(name) > cronJobNameMaxLength {
+		name = fmt.Sprintf(keyRotationNameFmt, k8sutil.Hash(pvcName))
+	}
+	return name
+}
+
+// checkKeyRotation restarts the encrypted osds whose key was rotated since they started, so that
+// they are verified to open their blocks with the new key of their secret, and reports the status
+// of the last rotation of each osd in the status of the cluster. A single osd is restarted per
+// check, and only if it is ok to stop.
+func (m *OSDHealthMonitor) checkKeyRotation() error {
+	secrets, err := m.context.Clientset.CoreV1().Secrets(m.clusterInfo.Namespace).List(metav1.ListOptions{LabelSelector: encryptionSecretPVCLabelKey})
+	if err != nil {
+		return errors.Wrap(err, "failed to list the osd encryption key secrets")
+	}
+
+	statuses := []cephv1.OSDKeyRotationStatus{}
+	restarted := false
+	for _, secret := range secrets.Items {
+		rotationStatus, ok := secret.Annotations[KeyRotationStatusAnnotation]
+		if !ok {
+			continue
+		}
+		status := cephv1.OSDKeyRotationStatus{
+			PVC:          secret.Labels[encryptionSecretPVCLabelKey],
+			OSD:          unknownID,
+			Status:       rotationStatus,
+			LastRotation: secret.Annotations[KeyRotationTimeAnnotation],
+		}
+		var restartedOSD bool
+		status.OSD, status.Status, restartedOSD, err = m.verifyKeyRotation(status.PVC, rotationStatus, status.LastRotation, !restarted)
+		if err != nil {
+			logger.Errorf("failed to verify the key rotation of the osd on pvc %q. %v", status.PVC, err)
+			status.Status = rotationStatus
+		}
+		restarted = restarted || restartedOSD
+		statuses = append(statuses, status)
+	}
+	m.updateKeyRotationStatus(statuses)
+	return nil
+}
+
+// verifyKeyRotation returns the id of the osd on the pvc and the status of its key rotation. Once
+// the rotation job succeeded, the pods of the osd started before the rotation are restarted if the
+// restart is allowed and the osd is ok to stop, and the osd is verified once it runs again.
+func (m *OSDHealthMonitor) verifyKeyRotation(pvcName, rotationStatus, rotationTime string, canRestart bool) (int, string, bool, error) {
+	selector := fmt.Sprintf("%s=%s,%s=%s", k8sutil.AppAttr, AppName, OSDOverPVCLabelKey, pvcName)
+	deployments, err := m.context.Clientset.AppsV1().Deployments(m.clusterInfo.Namespace).List(metav1.ListOptions{LabelSelector: selector})
+	if err != nil {
+		return unknownID, "", false, errors.Wrapf(err, "failed to list the osd deployments of pvc %q", pvcName)
+	}
+	if len(deployments.Items) == 0 {
+		return unknownID, rotationStatus, false, nil
+	}
+	d := deployments.Items[0]
+	osdID, err := strconv.Atoi(d.Labels[OsdIdLabelKey])
+	if err != nil {
+		return unknownID, "", false, errors.Wrapf(err, "failed to parse the osd id of deployment %q", d.Name)
+	}
+	if rotationStatus != KeyRotationSucceeded {
+		return osdID, rotationStatus, false, nil
+	}
+	rotated, err := time.Parse(time.RFC3339, rotationTime)
+	if err != nil {
+		return osdID, "", false, errors.Wrapf(err, "failed to parse the key rotation time %q", rotationTime)
+	}
+
+	pods := m.context.Clientset.CoreV1().Pods(m.clusterInfo.Namespace)
+	podList, err := pods.List(metav1.ListOptions{LabelSelector: selector})
+	if err != nil {
+		return osdID, "", false, errors.Wrapf(err, "failed to list the pods of osd.%d", osdID)
+	}
+	stalePods := []string{}
+	verified := false
+	for i := range podList.Items {
+		pod := &podList.Items[i]
+		if pod.DeletionTimestamp != nil {
+			continue
+		}
+		if !pod.CreationTimestamp.Time.After(rotated) {
+			stalePods = append(stalePods, pod.Name)
+		} else if isPodReady(pod) {
+			verified = true
+		}
+	}
+	if len(stalePods) == 0 {
+		if verified {
+			return osdID, KeyRotationVerified, false, nil
+		}
+		return osdID, KeyRotationRestarting, false, nil
+	}
+
+	if !canRestart {
+		return osdID, KeyRotationRestarting, false, nil
+	}
+	if err := client.OkToStop(m.context, m.clusterInfo, d.Name, "osd", strconv.Itoa(osdID)); err != nil {
+		logger.Infof("not restarting osd.%d to verify its new encryption key yet. %v", osdID, err)
+		return osdID, KeyRotationRestarting, false, nil
+	}
+	logger.Infof("restarting osd.%d to verify its new encryption key", osdID)
+	for _, name := range stalePods {
+		if err := pods.Delete(name, &metav1.DeleteOptions{}); err != nil {
+			return osdID, "", false, errors.Wrapf(err, "failed to restart pod %q of osd.%d", name, osdID)
+		}
+	}
+	return osdID, KeyRotationRestarting, true, nil
+}
+
+// updateKeyRotationStatus reports the key rotation status of the osds in the status of the cluster
+func (m *OSDHealthMonitor) updateKeyRotationStatus(statuses []cephv1.OSDKeyRotationStatus) {
+	if len(statuses) == 0 {
+		statuses = nil
+	}
+	cephCluster := &cephv1.CephCluster{}
+	err := m.context.Client.Get(context.TODO(), m.clusterInfo.NamespacedName(), cephCluster)
+	if err != nil {
+		if kerrors.IsNotFound(err) {
+			logger.Debug("CephCluster resource not found. Ignoring since object must be deleted.")
+			return
+		}
+		logger.Errorf("failed to retrieve ceph cluster %q to update the key rotation status. %v", m.clusterInfo.NamespacedName().Name, err)
+		return
+	}
+	if cephCluster.Status.CephStorage == nil {
+		cephCluster.Status.CephStorage = &cephv1.CephStorage{}
+	}
+	if reflect.DeepEqual(cephCluster.Status.CephStorage.KeyRotation, statuses) {
+		return
+	}
+	cephCluster.Status.CephStorage.KeyRotation = statuses
+	if err := controller.UpdateStatus(m.context.Client, cephCluster); err != nil {
+		logger.Errorf("failed to update the key rotation status of cluster %q. %v", m.clusterInfo.NamespacedName().Name, err)
+	}
+}
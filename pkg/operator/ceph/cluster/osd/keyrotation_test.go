@@ -0,0 +1,181 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package osd
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	rookv1 "github.com/rook/rook/pkg/apis/rook.io/v1"
+	"github.com/rook/rook/pkg/client/clientset/versioned/scheme"
+	"github.com/rook/rook/pkg/clusterd"
+	"github.com/rook/rook/pkg/daemon/ceph/client"
+	"github.com/rook/rook/pkg/operator/k8sutil"
+	exectest "github.com/rook/rook/pkg/util/exec/test"
+	"github.com/stretchr/testify/assert"
+	apps "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	cfake "sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func newKeyRotationTestVolume(name string, encrypted bool, sources ...string) rookv1.VolumeSource {
+	volume := rookv1.VolumeSource{Name: name, Encrypted: encrypted, PVCSources: map[string]v1.PersistentVolumeClaimVolumeSource{}}
+	for _, source := range sources {
+		volume.PVCSources[source] = v1.PersistentVolumeClaimVolumeSource{ClaimName: name + "-" + source}
+	}
+	return volume
+}
+
+func TestReconcileKeyRotation(t *testing.T) {
+	clientset := fake.NewSimpleClientset()
+	context := &clusterd.Context{Clientset: clientset}
+	spec := cephv1.ClusterSpec{Security: cephv1.SecuritySpec{KeyRotation: cephv1.KeyRotationSpec{Enabled: true}}}
+	c := New(context, &client.ClusterInfo{Namespace: "ns"}, spec, "myversion")
+	c.ValidStorage.VolumeSources = []rookv1.VolumeSource{
+		newKeyRotationTestVolume("set1", true, bluestorePVCData, bluestorePVCMetadata),
+		newKeyRotationTestVolume("set2", false, bluestorePVCData),
+	}
+
+	// a cron job is created for the encrypted osd only
+	err := c.reconcileKeyRotation()
+	assert.NoError(t, err)
+	cronJobs, err := clientset.BatchV1beta1().CronJobs("ns").List(metav1.ListOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(cronJobs.Items))
+	cronJob := cronJobs.Items[0]
+	assert.Equal(t, "osd-key-rotation-set1-data", cronJob.Name)
+	assert.Equal(t, defaultKeyRotationSchedule, cronJob.Spec.Schedule)
+	podSpec := cronJob.Spec.JobTemplate.Spec.Template.Spec
+	assert.Equal(t, 2, len(podSpec.Volumes))
+	args := strings.Join(podSpec.Containers[0].Args, " ")
+	assert.Contains(t, args, "--secret-name rook-ceph-osd-encryption-key-set1-data")
+	assert.Contains(t, args, "--encrypted-blocks /set1-data,/set1-metadata")
+	assert.Equal(t, "set1-data", podSpec.Affinity.PodAffinity.RequiredDuringSchedulingIgnoredDuringExecution[0].LabelSelector.MatchLabels[OSDOverPVCLabelKey])
+
+	// the schedule is updated
+	c.spec.Security.KeyRotation.Schedule = "@monthly"
+	err = c.reconcileKeyRotation()
+	assert.NoError(t, err)
+	cronJobs, err = clientset.BatchV1beta1().CronJobs("ns").List(metav1.ListOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(cronJobs.Items))
+	assert.Equal(t, "@monthly", cronJobs.Items[0].Spec.Schedule)
+
+	// the cron jobs are deleted when the rotation is disabled
+	c.spec.Security.KeyRotation.Enabled = false
+	err = c.reconcileKeyRotation()
+	assert.NoError(t, err)
+	cronJobs, err = clientset.BatchV1beta1().CronJobs("ns").List(metav1.ListOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, 0, len(cronJobs.Items))
+}
+
+func TestKeyRotationCronJobName(t *testing.T) {
+	assert.Equal(t, "osd-key-rotation-set1-data-0-abcde", keyRotationCronJobName("set1-data-0-abcde"))
+	name := keyRotationCronJobName(strings.Repeat("a", 40))
+	assert.True(t, len(name) <= cronJobNameMaxLength)
+}
+
+func TestCheckKeyRotation(t *testing.T) {
+	rotated := time.Now().Add(-time.Hour).UTC()
+	secret := &v1.Secret{ObjectMeta: metav1.ObjectMeta{
+		Name:      generateOSDEncryptionSecretName("set1-data-0"),
+		Namespace: "ns",
+		Labels:    map[string]string{encryptionSecretPVCLabelKey: "set1-data-0"},
+		Annotations: map[string]string{
+			KeyRotationStatusAnnotation: KeyRotationSucceeded,
+			KeyRotationTimeAnnotation:   rotated.Format(time.RFC3339),
+		},
+	}}
+	labels := map[string]string{k8sutil.AppAttr: AppName, OsdIdLabelKey: "0", OSDOverPVCLabelKey: "set1-data-0"}
+	d := &apps.Deployment{ObjectMeta: metav1.ObjectMeta{Name: "rook-ceph-osd-0", Namespace: "ns", Labels: labels}}
+	// the pod of the osd started before the rotation
+	pod := &v1.Pod{ObjectMeta: metav1.ObjectMeta{
+		Name:              "rook-ceph-osd-0-abc",
+		Namespace:         "ns",
+		Labels:            labels,
+		CreationTimestamp: metav1.NewTime(rotated.Add(-time.Hour)),
+	}}
+	clientset := fake.NewSimpleClientset(secret, d, pod)
+
+	okToStop := false
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutputFile: func(command string, outFileArg string, args ...string) (string, error) {
+			if !okToStop {
+				return "", errors.New("not ok to stop")
+			}
+			switch args[0] {
+			case "versions":
+				return `{"osd": {}}`, nil
+			case "osd":
+				if args[1] == "ls" {
+					return "[0]", nil
+				}
+			}
+			return "", errors.Errorf("unexpected command %v", args)
+		},
+	}
+	clusterInfo := client.AdminClusterInfo("ns")
+	clusterInfo.SetName("rook-ceph")
+	cephCluster := &cephv1.CephCluster{ObjectMeta: metav1.ObjectMeta{Name: "rook-ceph", Namespace: "ns"}}
+	cl := cfake.NewFakeClientWithScheme(scheme.Scheme, cephCluster)
+	m := NewOSDHealthMonitor(&clusterd.Context{Clientset: clientset, Client: cl, Executor: executor}, clusterInfo, false, cephv1.CephClusterHealthCheckSpec{})
+	keyRotationStatus := func() cephv1.OSDKeyRotationStatus {
+		err := cl.Get(context.TODO(), clusterInfo.NamespacedName(), cephCluster)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, len(cephCluster.Status.CephStorage.KeyRotation))
+		return cephCluster.Status.CephStorage.KeyRotation[0]
+	}
+
+	// the osd is not restarted while it is not ok to stop
+	assert.NoError(t, m.checkKeyRotation())
+	status := keyRotationStatus()
+	assert.Equal(t, cephv1.OSDKeyRotationStatus{PVC: "set1-data-0", OSD: 0, Status: KeyRotationRestarting, LastRotation: rotated.Format(time.RFC3339)}, status)
+	_, err := clientset.CoreV1().Pods("ns").Get(pod.Name, metav1.GetOptions{})
+	assert.NoError(t, err)
+
+	// the osd is restarted
+	okToStop = true
+	assert.NoError(t, m.checkKeyRotation())
+	assert.Equal(t, KeyRotationRestarting, keyRotationStatus().Status)
+	_, err = clientset.CoreV1().Pods("ns").Get(pod.Name, metav1.GetOptions{})
+	assert.Error(t, err)
+
+	// the osd runs with its new key
+	pod.Name = "rook-ceph-osd-0-def"
+	pod.CreationTimestamp = metav1.NewTime(rotated.Add(time.Minute))
+	pod.Status.Conditions = []v1.PodCondition{{Type: v1.PodReady, Status: v1.ConditionTrue}}
+	_, err = clientset.CoreV1().Pods("ns").Create(pod)
+	assert.NoError(t, err)
+	assert.NoError(t, m.checkKeyRotation())
+	assert.Equal(t, KeyRotationVerified, keyRotationStatus().Status)
+
+	// a failed rotation is reported
+	secret.Annotations[KeyRotationStatusAnnotation] = KeyRotationFailed
+	_, err = clientset.CoreV1().Secrets("ns").Update(secret)
+	assert.NoError(t, err)
+	assert.NoError(t, m.checkKeyRotation())
+	status = keyRotationStatus()
+	assert.Equal(t, KeyRotationFailed, status.Status)
+	assert.Equal(t, 0, status.OSD)
+}
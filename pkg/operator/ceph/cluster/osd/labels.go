@@ -32,6 +32,9 @@ const (
 	CephDeviceSetPVCIDLabelKey = "ceph.rook.io/DeviceSetPVCId"
 	// OSDOverPVCLabelKey is the Rook PVC label key
 	OSDOverPVCLabelKey = "ceph.rook.io/pvc"
+	// OSDRemovalLabelKey is set on the deployment of an OSD stopped by a CephOSDRemoval until the OSD
+	// is purged, the orchestration doesn't update the deployment while it is set
+	OSDRemovalLabelKey = "ceph.rook.io/osd-removal"
 )
 
 func makeStorageClassDeviceSetPVCLabel(storageClassDeviceSetName, pvcStorageClassDeviceSetPVCId string, setIndex int) map[string]string {
@@ -0,0 +1,178 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package osd
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/rook/rook/pkg/clusterd"
+	"github.com/rook/rook/pkg/daemon/ceph/client"
+	apps "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// pvcOSDIDsAnnotation records on a pvc carved into several osds the ids of the osds that are
+	// started. The osds removed from the pvc are dropped from the list so they are not started again
+	// when the lvs of the pvc are listed by the prepare job.
+	pvcOSDIDsAnnotation = "ceph.rook.io/osd-ids"
+)
+
+// pvcOSDIDs returns the ids of the osds recorded on the pvc, or nil if they were never recorded
+func pvcOSDIDs(context *clusterd.Context, namespace, pvcName string) ([]int, error) {
+	pvc, err := context.Clientset.CoreV1().PersistentVolumeClaims(namespace).Get(pvcName, metav1.GetOptions{})
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get pvc %q", pvcName)
+	}
+	recorded, ok := pvc.Annotations[pvcOSDIDsAnnotation]
+	if !ok {
+		return nil, nil
+	}
+	ids := []int{}
+	for _, value := range strings.Split(recorded, ",") {
+		if value == "" {
+			continue
+		}
+		id, err := strconv.Atoi(value)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to parse the osd ids %q of pvc %q", recorded, pvcName)
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// setPVCOSDIDs records the ids of the osds started on the pvc
+func setPVCOSDIDs(context *clusterd.Context, namespace, pvcName string, ids []int) error {
+	pvcs := context.Clientset.CoreV1().PersistentVolumeClaims(namespace)
+	pvc, err := pvcs.Get(pvcName, metav1.GetOptions{})
+	if err != nil {
+		return errors.Wrapf(err, "failed to get pvc %q", pvcName)
+	}
+	sort.Ints(ids)
+	values := []string{}
+	for _, id := range ids {
+		values = append(values, strconv.Itoa(id))
+	}
+	if pvc.Annotations == nil {
+		pvc.Annotations = map[string]string{}
+	}
+	pvc.Annotations[pvcOSDIDsAnnotation] = strings.Join(values, ",")
+	if _, err := pvcs.Update(pvc); err != nil {
+		return errors.Wrapf(err, "failed to record the osds of pvc %q", pvcName)
+	}
+	return nil
+}
+
+// RemoveOSDFromPVC drops the osd from the osds recorded on the pvc so it is not started again when
+// the other osds carved out of the pvc are started
+func RemoveOSDFromPVC(context *clusterd.Context, namespace, pvcName string, osdID int) error {
+	ids, err := pvcOSDIDs(context, namespace, pvcName)
+	if err != nil || ids == nil {
+		return err
+	}
+	kept := []int{}
+	for _, id := range ids {
+		if id != osdID {
+			kept = append(kept, id)
+		}
+	}
+	return setPVCOSDIDs(context, namespace, pvcName, kept)
+}
+
+// osdsToStartOnPVC returns the osds reported by the prepare job of a pvc carved into several osds
+// that must be started. The osds reported the first time are recorded on the pvc. The osds removed
+// from the pvc or purged from the cluster are not started again, their lvs are still on the pvc.
+func (c *Cluster) osdsToStartOnPVC(pvcName string, osds []OSDInfo) ([]OSDInfo, error) {
+	recorded, err := pvcOSDIDs(c.context, c.clusterInfo.Namespace, pvcName)
+	if err != nil {
+		return nil, err
+	}
+	osdDump, err := client.GetOSDDump(c.context, c.clusterInfo)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get osd dump")
+	}
+
+	result := []OSDInfo{}
+	for _, osd := range osds {
+		if _, _, err := osdDump.StatusByID(int64(osd.ID)); err != nil {
+			logger.Infof("not starting osd.%d on pvc %q, it was purged", osd.ID, pvcName)
+			continue
+		}
+		if recorded != nil && !containsID(recorded, osd.ID) {
+			logger.Infof("not starting osd.%d on pvc %q, it was removed", osd.ID, pvcName)
+			continue
+		}
+		result = append(result, osd)
+	}
+
+	if recorded == nil {
+		ids := []int{}
+		for _, osd := range result {
+			ids = append(ids, osd.ID)
+		}
+		if err := setPVCOSDIDs(c.context, c.clusterInfo.Namespace, pvcName, ids); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+// multiOSDPVCStarted returns whether all the osds carved out of the pvc are started. The osds
+// recorded on the pvc must have a deployment, except the osds purged from the cluster.
+func (c *Cluster) multiOSDPVCStarted(pvcName string, deployments []apps.Deployment) (bool, error) {
+	recorded, err := pvcOSDIDs(c.context, c.clusterInfo.Namespace, pvcName)
+	if err != nil || recorded == nil {
+		return false, err
+	}
+	started := map[string]bool{}
+	for _, d := range deployments {
+		started[d.Labels[OsdIdLabelKey]] = true
+	}
+	missing := []int{}
+	for _, id := range recorded {
+		if !started[strconv.Itoa(id)] {
+			missing = append(missing, id)
+		}
+	}
+	if len(missing) == 0 {
+		return true, nil
+	}
+
+	osdDump, err := client.GetOSDDump(c.context, c.clusterInfo)
+	if err != nil {
+		return false, errors.Wrap(err, "failed to get osd dump")
+	}
+	for _, id := range missing {
+		if _, _, err := osdDump.StatusByID(int64(id)); err == nil {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+func containsID(ids []int, id int) bool {
+	for _, i := range ids {
+		if i == id {
+			return true
+		}
+	}
+	return false
+}
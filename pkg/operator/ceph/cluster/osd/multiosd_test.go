@@ -0,0 +1,86 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package osd
+
+import (
+	"testing"
+
+	"github.com/rook/rook/pkg/clusterd"
+	"github.com/rook/rook/pkg/daemon/ceph/client"
+	exectest "github.com/rook/rook/pkg/util/exec/test"
+	"github.com/stretchr/testify/assert"
+	apps "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestMultiOSDPVC(t *testing.T) {
+	pvc := &v1.PersistentVolumeClaim{ObjectMeta: metav1.ObjectMeta{Name: "set1-data-0", Namespace: "ns"}}
+	clientset := fake.NewSimpleClientset(pvc)
+	// osd.1 was purged
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutputFile: func(command string, outFileArg string, args ...string) (string, error) {
+			if args[0] == "osd" && args[1] == "dump" {
+				return `{"OSDs": [{"OSD": 0, "Up": 1, "In": 1}, {"OSD": 2, "Up": 1, "In": 1}]}`, nil
+			}
+			return "", nil
+		},
+	}
+	c := &Cluster{
+		context:     &clusterd.Context{Clientset: clientset, Executor: executor},
+		clusterInfo: client.AdminClusterInfo("ns"),
+	}
+	newDeployment := func(id string) apps.Deployment {
+		return apps.Deployment{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{OsdIdLabelKey: id}}}
+	}
+
+	// the osds were never recorded
+	started, err := c.multiOSDPVCStarted("set1-data-0", nil)
+	assert.NoError(t, err)
+	assert.False(t, started)
+
+	// the osds reported the first time are recorded, except the purged osd
+	osds, err := c.osdsToStartOnPVC("set1-data-0", []OSDInfo{{ID: 0}, {ID: 1}, {ID: 2}})
+	assert.NoError(t, err)
+	assert.Equal(t, []OSDInfo{{ID: 0}, {ID: 2}}, osds)
+	ids, err := pvcOSDIDs(c.context, "ns", "set1-data-0")
+	assert.NoError(t, err)
+	assert.Equal(t, []int{0, 2}, ids)
+
+	started, err = c.multiOSDPVCStarted("set1-data-0", []apps.Deployment{newDeployment("0")})
+	assert.NoError(t, err)
+	assert.False(t, started)
+	started, err = c.multiOSDPVCStarted("set1-data-0", []apps.Deployment{newDeployment("0"), newDeployment("2")})
+	assert.NoError(t, err)
+	assert.True(t, started)
+
+	// the removed osd is not started again
+	assert.NoError(t, RemoveOSDFromPVC(c.context, "ns", "set1-data-0", 2))
+	osds, err = c.osdsToStartOnPVC("set1-data-0", []OSDInfo{{ID: 0}, {ID: 1}, {ID: 2}})
+	assert.NoError(t, err)
+	assert.Equal(t, []OSDInfo{{ID: 0}}, osds)
+	started, err = c.multiOSDPVCStarted("set1-data-0", []apps.Deployment{newDeployment("0")})
+	assert.NoError(t, err)
+	assert.True(t, started)
+
+	// the purged osds are not waited for
+	assert.NoError(t, setPVCOSDIDs(c.context, "ns", "set1-data-0", []int{0, 1}))
+	started, err = c.multiOSDPVCStarted("set1-data-0", []apps.Deployment{newDeployment("0")})
+	assert.NoError(t, err)
+	assert.True(t, started)
+}
@@ -74,6 +74,11 @@ const (
 	bluestorePVCMetadata                = "metadata"
 	bluestorePVCWal                     = "wal"
 	bluestorePVCData                    = "data"
+	// the share of the memory limit of the osd pod given to the memory target of the osd
+	osdMemoryTargetHDDRatio   = 0.8
+	osdMemoryTargetFlashRatio = 0.7
+	// the minimum memory target accepted by ceph
+	osdMemoryTargetMin = 896 * 1024 * 1024
 )
 
 // Cluster keeps track of the OSDs
@@ -84,6 +89,8 @@ type Cluster struct {
 	spec         cephv1.ClusterSpec
 	ValidStorage rookv1.StorageScopeSpec // valid subset of `Storage`, computed at runtime
 	kv           *k8sutil.ConfigMapKVStore
+	// the crush device class of the existing osds, loaded when the osds have settings per device class
+	deviceClasses map[int]string
 }
 
 // New creates an instance of the OSD manager
@@ -112,6 +119,10 @@ type OSDInfo struct {
 	LVBackedPV    bool   `json:"lv-backed-pv"`
 	CVMode        string `json:"lv-mode"`
 	Store         string `json:"store"`
+	// DevicePath is the path of the device backing the OSD on the node, e.g. /dev/sdb
+	DevicePath string `json:"device-path"`
+	// DeviceID is the WWN or the serial of the device, used to detect when the device was swapped
+	DeviceID string `json:"device-id"`
 }
 
 // OrchestrationStatus represents the status of an OSD orchestration
@@ -136,6 +147,7 @@ type osdProperties struct {
 	placement           rookv1.Placement
 	preparePlacement    *rookv1.Placement
 	metadataDevice      string
+	walDevice           string
 	location            string
 	portable            bool
 	tuneSlowDeviceClass bool
@@ -178,6 +190,11 @@ func (c *Cluster) Start() error {
 	}
 	logger.Infof("start running osds in namespace %s", c.clusterInfo.Namespace)
 
+	c.warnDeviceClassNodeAffinity()
+	if err := c.loadDeviceClasses(); err != nil {
+		return err
+	}
+
 	if !c.spec.Storage.UseAllNodes && len(c.spec.Storage.Nodes) == 0 && len(c.spec.Storage.VolumeSources) == 0 && len(c.spec.Storage.StorageClassDeviceSets) == 0 && len(c.spec.DriveGroups) == 0 {
 		logger.Warningf("useAllNodes is set to false and no nodes, driveGroups, storageClassDevicesets or volumeSources are specified, no OSD pods are going to be created")
 	}
@@ -189,6 +206,10 @@ func (c *Cluster) Start() error {
 	logger.Infof("start provisioning the osds on nodes, if needed")
 	c.startProvisioningOverNodes(config)
 
+	if err := c.reconcileKeyRotation(); err != nil {
+		config.addError("failed to reconcile the rotation of the osd encryption keys. %v", err)
+	}
+
 	if len(config.errorMessages) > 0 {
 		return errors.Errorf("%d failures encountered while running osds in namespace %s: %+v",
 			len(config.errorMessages), c.clusterInfo.Namespace, strings.Join(config.errorMessages, "\n"))
@@ -226,6 +247,9 @@ func (c *Cluster) startProvisioningOverPVCs(config *provisionConfig) {
 		return
 	}
 
+	// the prepare jobs are started in parallel since replacing the job of a pvc waits for the
+	// previous job to be deleted
+	pool := c.newProvisioningPool()
 	for _, volume := range c.ValidStorage.VolumeSources {
 		dataSource, dataOK := volume.PVCSources[bluestorePVCData]
 
@@ -259,10 +283,18 @@ func (c *Cluster) startProvisioningOverPVCs(config *provisionConfig) {
 			crushDeviceClass: volume.CrushDeviceClass,
 			schedulerName:    volume.SchedulerName,
 			encrypted:        volume.Encrypted,
+			storeConfig:      pvcStoreConfig(volume.Config),
 		}
 
 		logger.Debugf("osdProps are %+v", osdProps)
 
+		// several osds are carved out of the data pvc with 'ceph-volume lvm batch', which doesn't
+		// support the metadata and wal pvcs nor the encryption
+		if osdProps.storeConfig.OSDsPerDevice > 1 && (metadataOK || walOK || osdProps.encrypted) {
+			config.addError("failed to validate storageClassDeviceSet %q. osdsPerDevice is not supported with metadata or wal pvcs or with encryption", volume.Name)
+			continue
+		}
+
 		if osdProps.encrypted {
 			// If the deviceSet template has "encrypted" but the Ceph version is not compatible
 			if !c.isCephVolumeRawModeSupported() {
@@ -310,9 +342,20 @@ func (c *Cluster) startProvisioningOverPVCs(config *provisionConfig) {
 			continue
 		}
 
-		if len(osdDeployments.Items) != 0 {
+		// the prepare job runs again until there is a deployment for each of the osds of the pvc,
+		// the osds already prepared are found by the job. The osds purged from a pvc carved into
+		// several osds are not counted.
+		started := len(osdDeployments.Items) != 0 && len(osdDeployments.Items) >= osdProps.storeConfig.OSDsPerDevice
+		if !started && osdProps.storeConfig.OSDsPerDevice > 1 {
+			started, err = c.multiOSDPVCStarted(dataSource.ClaimName, osdDeployments.Items)
+			if err != nil {
+				config.addError("failed to check if the osds of pvc %q are started. %v", osdProps.crushHostname, err)
+				continue
+			}
+		}
+		if started {
 			logger.Infof("skip OSD prepare pod creation as OSD daemon already exists for %q", osdProps.crushHostname)
-			osds, err := c.getOSDInfo(&osdDeployments.Items[0])
+			osds, err := c.getDeploymentsOSDInfo(osdDeployments.Items)
 			if err != nil {
 				config.addError("failed to get osdInfo for pvc %q. %v", osdProps.crushHostname, err)
 				continue
@@ -323,23 +366,27 @@ func (c *Cluster) startProvisioningOverPVCs(config *provisionConfig) {
 			continue
 		}
 
-		job, err := c.makeJob(osdProps, config)
-		if err != nil {
-			message := fmt.Sprintf("failed to create prepare job for pvc %s: %v", osdProps.crushHostname, err)
-			config.addError(message)
-			status := OrchestrationStatus{Status: OrchestrationStatusCompleted, Message: message, PvcBackedOSD: true}
-			c.updateOSDStatus(osdProps.crushHostname, status)
-		}
+		pool.run(func() {
+			job, err := c.makeJob(osdProps, config)
+			if err != nil {
+				message := fmt.Sprintf("failed to create prepare job for pvc %s: %v", osdProps.crushHostname, err)
+				config.addError(message)
+				status := OrchestrationStatus{Status: OrchestrationStatusCompleted, Message: message, PvcBackedOSD: true}
+				c.updateOSDStatus(osdProps.crushHostname, status)
+			}
 
-		if !c.runJob(job, osdProps.crushHostname, config, "provision") {
-			status := OrchestrationStatus{
-				Status:       OrchestrationStatusCompleted,
-				Message:      fmt.Sprintf("failed to start osd provisioning on pvc %s", osdProps.crushHostname),
-				PvcBackedOSD: true,
+			if !c.runJob(job, osdProps.crushHostname, config, "provision") {
+				status := OrchestrationStatus{
+					Status:       OrchestrationStatusCompleted,
+					Message:      fmt.Sprintf("failed to start osd provisioning on pvc %s", osdProps.crushHostname),
+					PvcBackedOSD: true,
+				}
+				c.updateOSDStatus(osdProps.crushHostname, status)
 			}
-			c.updateOSDStatus(osdProps.crushHostname, status)
-		}
+		})
 	}
+	pool.wait()
+
 	logger.Infof("start osds after provisioning is completed, if needed")
 	c.completeProvision(config)
 }
@@ -400,7 +447,8 @@ func (c *Cluster) startNodeStorageProvisioners(config *provisionConfig) {
 		return
 	}
 
-	// start with nodes currently in the storage spec
+	// start with nodes currently in the storage spec, a single job prepares all the osds of a node
+	pool := c.newProvisioningPool()
 	for _, node := range c.ValidStorage.Nodes {
 		// fully resolve the storage config and resources for this node
 		n := c.resolveNode(node.Name)
@@ -424,9 +472,13 @@ func (c *Cluster) startNodeStorageProvisioners(config *provisionConfig) {
 			resources:      n.Resources,
 			storeConfig:    storeConfig,
 			metadataDevice: metadataDevice,
+			walDevice:      osdconfig.WalDevice(n.Config),
 		}
-		c.makeAndRunJob(n.Name, "provision", osdProps, config)
+		pool.run(func() {
+			c.makeAndRunJob(n.Name, "provision", osdProps, config)
+		})
 	}
+	pool.wait()
 }
 
 func (c *Cluster) startNodeDriveGroupProvisioners(config *provisionConfig) {
@@ -451,6 +503,7 @@ func (c *Cluster) startNodeDriveGroupProvisioners(config *provisionConfig) {
 	c.spec.Storage.Nodes = nil
 
 	sanitizedDGs := SanitizeDriveGroups(c.spec.DriveGroups)
+	pool := c.newProvisioningPool()
 
 	// Drive Groups should considered on every node in the k8s cluster; each drive group's
 	// 'placement' should be the selector for placement across all of K8s' nodes and not be affected
@@ -477,8 +530,11 @@ func (c *Cluster) startNodeDriveGroupProvisioners(config *provisionConfig) {
 			crushHostname: normalizedHostname,
 			driveGroups:   groups,
 		}
-		c.makeAndRunJob(normalizedHostname, "provision drive groups", osdProps, config)
+		pool.run(func() {
+			c.makeAndRunJob(normalizedHostname, "provision drive groups", osdProps, config)
+		})
 	}
+	pool.wait()
 
 	// With Drive Groups, any node *could* be valid, and we need to do this so nodes resolve when
 	// starting OSD daemons. Each DGroup's individual placement will determine if the group is valid
@@ -529,6 +585,13 @@ func (c *Cluster) startOSDDaemonsOnPVC(pvcName string, config *provisionConfig,
 		config.addError(fmt.Sprintf("%v", err))
 		return
 	}
+	if osdProps.storeConfig.OSDsPerDevice > 1 {
+		osds, err = c.osdsToStartOnPVC(pvcName, osds)
+		if err != nil {
+			config.addError("failed to find the osds to start on pvc %q. %v", pvcName, err)
+			return
+		}
+	}
 
 	// start osds
 	for _, osd := range osds {
@@ -543,7 +606,7 @@ func (c *Cluster) startOSDDaemonsOnPVC(pvcName string, config *provisionConfig,
 			continue
 		}
 
-		dp, err := c.makeDeployment(osdProps, osd, config)
+		dp, err := c.makeDeployment(c.deviceClassOSDProps(osdProps, osd.ID, ""), osd, config)
 		if err != nil {
 			errMsg := fmt.Sprintf("failed to create deployment for pvc %q. %v", osdProps.crushHostname, err)
 			config.addError(errMsg)
@@ -562,21 +625,13 @@ func (c *Cluster) startOSDDaemonsOnPVC(pvcName string, config *provisionConfig,
 		if createErr != nil {
 			if kerrors.IsAlreadyExists(createErr) {
 				logger.Infof("deployment for osd %d already exists. updating if needed", osd.ID)
-				if err = updateDeploymentAndWait(c.context, c.clusterInfo, dp, opconfig.OsdType, strconv.Itoa(osd.ID), c.spec.SkipUpgradeChecks, c.spec.ContinueUpgradeAfterChecksEvenIfNotHealthy); err != nil {
-					logger.Errorf("failed to update osd deployment %d. %v", osd.ID, err)
-				}
+				config.addDeploymentUpdate(osd.ID, dp)
 			} else {
 				// we failed to create job, update the orchestration status for this pvc
 				logger.Warningf("failed to create osd deployment for pvc %q, osd %v. %v", osdProps.pvc.ClaimName, osd, createErr)
 				continue
 			}
 		}
-
-		if createErr != nil && kerrors.IsAlreadyExists(createErr) {
-			if err = updateDeploymentAndWait(c.context, c.clusterInfo, dp, opconfig.OsdType, strconv.Itoa(osd.ID), c.spec.SkipUpgradeChecks, c.spec.ContinueUpgradeAfterChecksEvenIfNotHealthy); err != nil {
-				logger.Errorf("failed to update osd deployment %d. %v", osd.ID, err)
-			}
-		}
 		logger.Infof("started deployment for osd %d on pvc", osd.ID)
 	}
 }
@@ -604,6 +659,13 @@ func (c *Cluster) startOSDDaemonsOnNode(nodeName string, config *provisionConfig
 		metadataDevice: metadataDevice,
 	}
 
+	// purge the osds whose device was swapped for the device of a new osd
+	if c.spec.Storage.AllowOsdReplace {
+		if err := c.replaceSwappedOSDs(n.Name, osds); err != nil {
+			config.addError("failed to replace the swapped osds on node %q. %v", n.Name, err)
+		}
+	}
+
 	// start osds
 	for _, osd := range osds {
 		logger.Debugf("start osd %v", osd)
@@ -617,7 +679,7 @@ func (c *Cluster) startOSDDaemonsOnNode(nodeName string, config *provisionConfig
 			continue
 		}
 
-		dp, err := c.makeDeployment(osdProps, osd, config)
+		dp, err := c.makeDeployment(c.deviceClassOSDProps(osdProps, osd.ID, nodeName), osd, config)
 		if err != nil {
 			errMsg := fmt.Sprintf("failed to create deployment for node %s: %v", n.Name, err)
 			config.addError(errMsg)
@@ -636,9 +698,7 @@ func (c *Cluster) startOSDDaemonsOnNode(nodeName string, config *provisionConfig
 		if createErr != nil {
 			if kerrors.IsAlreadyExists(createErr) {
 				logger.Debugf("deployment for osd %d already exists. updating if needed", osd.ID)
-				if err = updateDeploymentAndWait(c.context, c.clusterInfo, dp, opconfig.OsdType, strconv.Itoa(osd.ID), c.spec.SkipUpgradeChecks, c.spec.ContinueUpgradeAfterChecksEvenIfNotHealthy); err != nil {
-					logger.Errorf("failed to update osd deployment %d. %v", osd.ID, err)
-				}
+				config.addDeploymentUpdate(osd.ID, dp)
 			} else {
 				// we failed to create job, update the orchestration status for this pvc
 				logger.Warningf("failed to create osd deployment for node %q, osd %+v. %v", n.Name, osd, createErr)
@@ -650,6 +710,25 @@ func (c *Cluster) startOSDDaemonsOnNode(nodeName string, config *provisionConfig
 	}
 }
 
+// updateOSDDeployments updates the existing osd deployments one at a time once the osds of all the
+// nodes and PVCs are started. Each update waits for the osd to be ok to stop and for the updated osd
+// to run, so the osds of several failure domains are never restarted together.
+func (c *Cluster) updateOSDDeployments(config *provisionConfig) {
+	for _, update := range config.takeDeploymentUpdates() {
+		// the osds being removed stay stopped until they are purged
+		current, err := c.context.Clientset.AppsV1().Deployments(c.clusterInfo.Namespace).Get(update.deployment.Name, metav1.GetOptions{})
+		if err == nil {
+			if _, ok := current.Labels[OSDRemovalLabelKey]; ok {
+				logger.Infof("not updating the deployment of osd %d, the osd is being removed", update.osdID)
+				continue
+			}
+		}
+		if err := updateDeploymentAndWait(c.context, c.clusterInfo, update.deployment, opconfig.OsdType, strconv.Itoa(update.osdID), c.spec.SkipUpgradeChecks, c.spec.ContinueUpgradeAfterChecksEvenIfNotHealthy); err != nil {
+			logger.Errorf("failed to update osd deployment %d. %v", update.osdID, err)
+		}
+	}
+}
+
 // discover nodes which currently have osds scheduled on them. Return a mapping of
 // node names -> a list of osd deployments on the node
 func (c *Cluster) discoverStorageNodes() (map[string][]*apps.Deployment, error) {
@@ -693,6 +772,103 @@ func (c *Cluster) resolveNode(nodeName string) *rookv1.Node {
 	return rookNode
 }
 
+// loadDeviceClasses loads the crush device class of the existing osds if the placement or the
+// resources of the osds are set per device class
+func (c *Cluster) loadDeviceClasses() error {
+	c.deviceClasses = map[int]string{}
+	if !c.hasDeviceClassSettings() {
+		return nil
+	}
+	tree, err := cephclient.HostTree(c.context, c.clusterInfo)
+	if err != nil {
+		return errors.Wrap(err, "failed to get the device classes of the osds")
+	}
+	for _, node := range tree.Nodes {
+		if node.Type == "osd" && node.DeviceClass != "" {
+			c.deviceClasses[node.ID] = node.DeviceClass
+		}
+	}
+	return nil
+}
+
+// warnDeviceClassNodeAffinity warns about the node affinity in the placement of a device class. The
+// osds on nodes must run on the node of their devices, so they ignore it. The osds on pvcs use it.
+func (c *Cluster) warnDeviceClassNodeAffinity() {
+	prefix := cephv1.OSDDeviceClassKey("")
+	for key, placement := range c.spec.Placement {
+		if strings.HasPrefix(string(key), prefix) && placement.NodeAffinity != nil {
+			logger.Warningf("the node affinity of placement %q is ignored by the osds on nodes, only the osds on pvcs use it", key)
+		}
+	}
+}
+
+// nodeOSDPlacement returns the placement of the osds of a device class on nodes, without the node
+// affinity of the device class
+func (c *Cluster) nodeOSDPlacement(deviceClass string) rookv1.Placement {
+	placement := cephv1.GetOSDPlacement(c.spec.Placement)
+	if deviceClass == "" {
+		return placement
+	}
+	classPlacement := c.spec.Placement[rookv1.KeyType(cephv1.OSDDeviceClassKey(deviceClass))]
+	classPlacement.NodeAffinity = nil
+	return placement.Merge(classPlacement)
+}
+
+func (c *Cluster) hasDeviceClassSettings() bool {
+	prefix := cephv1.OSDDeviceClassKey("")
+	for key := range c.spec.Placement {
+		if strings.HasPrefix(string(key), prefix) {
+			return true
+		}
+	}
+	for key := range c.spec.Resources {
+		if strings.HasPrefix(key, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// deviceClassOSDProps returns the properties of an osd with the device class of the osd. The osds on
+// pvcs get the placement of the device class, which overrides the placement of their storage class
+// device set. The osds on the given node get the resources of the device class. The resources of
+// the node take precedence over the resources of the device class, which take precedence over the
+// resources of all the osds.
+func (c *Cluster) deviceClassOSDProps(osdProps osdProperties, osdID int, nodeName string) osdProperties {
+	deviceClass, ok := c.deviceClasses[osdID]
+	if !ok {
+		// the class of a new osd is only in the crush map once the osd started
+		deviceClass = osdProps.crushDeviceClass
+		if deviceClass == "" {
+			deviceClass = osdProps.storeConfig.DeviceClass
+		}
+	}
+	if deviceClass == "" {
+		return osdProps
+	}
+	osdProps.crushDeviceClass = deviceClass
+	if osdProps.onPVC() {
+		classPlacement := c.spec.Placement[rookv1.KeyType(cephv1.OSDDeviceClassKey(deviceClass))]
+		osdProps.placement = osdProps.placement.Merge(classPlacement)
+		return osdProps
+	}
+	classResources := cephv1.GetOSDDeviceClassResources(c.spec.Resources, deviceClass)
+	resources := k8sutil.MergeResourceRequirements(c.nodeResources(nodeName), classResources)
+	osdProps.resources = k8sutil.MergeResourceRequirements(resources, osdProps.resources)
+	return osdProps
+}
+
+// nodeResources returns a copy of the resources set on the node with the given name in the storage
+// spec, before they are merged with the resources of all the osds
+func (c *Cluster) nodeResources(nodeName string) v1.ResourceRequirements {
+	for _, node := range c.spec.Storage.Nodes {
+		if node.Name == nodeName {
+			return *node.Resources.DeepCopy()
+		}
+	}
+	return v1.ResourceRequirements{}
+}
+
 func (c *Cluster) getOSDPropsForPVC(pvcName string) (osdProperties, error) {
 
 	for _, volumeSource := range c.ValidStorage.VolumeSources {
@@ -726,9 +902,11 @@ func (c *Cluster) getOSDPropsForPVC(pvcName string) (osdProperties, error) {
 				preparePlacement:    volumeSource.PreparePlacement,
 				portable:            volumeSource.Portable,
 				tuneSlowDeviceClass: volumeSource.TuneSlowDeviceClass,
+				crushDeviceClass:    volumeSource.CrushDeviceClass,
 				pvcSize:             volumeSource.Size,
 				schedulerName:       volumeSource.SchedulerName,
 				encrypted:           volumeSource.Encrypted,
+				storeConfig:         pvcStoreConfig(volumeSource.Config),
 			}
 			// If OSD isn't portable, we're getting the host name either from the osd deployment that was already initialized
 			// or from the osd prepare job from initial creation.
@@ -745,6 +923,17 @@ func (c *Cluster) getOSDPropsForPVC(pvcName string) (osdProperties, error) {
 	return osdProperties{}, errors.Errorf("no valid VolumeSource found for pvc %s", pvcName)
 }
 
+// pvcStoreConfig returns the store config of the osds on the pvcs of a device set. Only the number
+// of osds per device applies to the osds on pvc, it is left unset for a single osd so that the
+// spec of the existing osds doesn't change.
+func pvcStoreConfig(config map[string]string) osdconfig.StoreConfig {
+	osdsPerDevice := osdconfig.ToStoreConfig(config).OSDsPerDevice
+	if osdsPerDevice <= 1 {
+		return osdconfig.StoreConfig{}
+	}
+	return osdconfig.StoreConfig{OSDsPerDevice: osdsPerDevice}
+}
+
 // getPVCHostName finds the node where an OSD pod should be assigned with a node selector.
 // First look for the node selector that was previously used for the OSD, or if a new OSD
 // check for the assignment of the OSD prepare job.
@@ -787,6 +976,19 @@ func (c *Cluster) getPVCHostName(pvcName string) (string, error) {
 	return "", errors.Errorf("node selector not found on deployment for osd with pvc %q", pvcName)
 }
 
+// getDeploymentsOSDInfo returns the info of the osds of the deployments
+func (c *Cluster) getDeploymentsOSDInfo(deployments []apps.Deployment) ([]OSDInfo, error) {
+	var osds []OSDInfo
+	for i := range deployments {
+		deploymentOSDs, err := c.getOSDInfo(&deployments[i])
+		if err != nil {
+			return nil, err
+		}
+		osds = append(osds, deploymentOSDs...)
+	}
+	return osds, nil
+}
+
 func (c *Cluster) getOSDInfo(d *apps.Deployment) ([]OSDInfo, error) {
 	container := d.Spec.Template.Spec.Containers[0]
 	var osd OSDInfo
@@ -35,6 +35,7 @@ import (
 	apps "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/watch"
@@ -454,3 +455,106 @@ func TestOSDPlacement(t *testing.T) {
 	assert.Equal(t, 1, len(result.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms))
 	assert.Equal(t, "label2", result.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms[0].MatchExpressions[0].Key)
 }
+
+func TestPVCStoreConfig(t *testing.T) {
+	// a single osd per pvc by default
+	assert.Equal(t, 0, pvcStoreConfig(nil).OSDsPerDevice)
+	assert.Equal(t, 0, pvcStoreConfig(map[string]string{"osdsPerDevice": "1"}).OSDsPerDevice)
+
+	// only the osds per device applies to the osds on pvc
+	storeConfig := pvcStoreConfig(map[string]string{"osdsPerDevice": "4", "databaseSizeMB": "1024"})
+	assert.Equal(t, 4, storeConfig.OSDsPerDevice)
+	assert.Equal(t, 0, storeConfig.DatabaseSizeMB)
+}
+
+func TestAddPVCPodAffinity(t *testing.T) {
+	spec := &v1.PodSpec{}
+	rookv1.Placement{}.ApplyToPodSpec(spec)
+	addPVCPodAffinity(spec, "set1-data-0")
+	terms := spec.Affinity.PodAffinity.RequiredDuringSchedulingIgnoredDuringExecution
+	assert.Equal(t, 1, len(terms))
+	assert.Equal(t, "set1-data-0", terms[0].LabelSelector.MatchLabels[OSDOverPVCLabelKey])
+	assert.Equal(t, v1.LabelHostname, terms[0].TopologyKey)
+}
+
+func TestDeviceClassOSDProps(t *testing.T) {
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutputFile: func(command string, outFileArg string, args ...string) (string, error) {
+			if args[0] == "osd" && args[1] == "tree" {
+				return `{"nodes": [{"id": -1, "name": "node1", "type": "host"},
+					{"id": 0, "name": "osd.0", "type": "osd", "device_class": "hdd"},
+					{"id": 1, "name": "osd.1", "type": "osd", "device_class": "nvme"}]}`, nil
+			}
+			return "", errors.Errorf("unexpected command %v", args)
+		},
+	}
+	context := &clusterd.Context{Clientset: fake.NewSimpleClientset(), Executor: executor}
+	hddResources := v1.ResourceRequirements{Limits: v1.ResourceList{v1.ResourceMemory: resource.MustParse("4Gi")}}
+	spec := cephv1.ClusterSpec{
+		Resources: rookv1.ResourceSpec{"osd-hdd": hddResources},
+		Placement: rookv1.PlacementSpec{"osd-nvme": rookv1.Placement{Tolerations: []v1.Toleration{{Key: "nvme"}}}},
+	}
+	c := New(context, cephclient.AdminClusterInfo("ns"), spec, "myversion")
+	err := c.loadDeviceClasses()
+	assert.NoError(t, err)
+	assert.Equal(t, map[int]string{0: "hdd", 1: "nvme"}, c.deviceClasses)
+
+	osdResources := v1.ResourceRequirements{Limits: v1.ResourceList{v1.ResourceCPU: resource.MustParse("2"), v1.ResourceMemory: resource.MustParse("2Gi")}}
+	osdProps := osdProperties{crushHostname: "node1.example.com", resources: osdResources}
+
+	// the resources of the device class take precedence over the resources of all the osds
+	props := c.deviceClassOSDProps(osdProps, 0, "node1")
+	assert.Equal(t, "hdd", props.crushDeviceClass)
+	assert.Equal(t, "4Gi", props.resources.Limits.Memory().String())
+	assert.Equal(t, "2", props.resources.Limits.Cpu().String())
+	assert.Equal(t, 1, len(c.spec.Resources["osd-hdd"].Limits))
+
+	// the resources of the node take precedence over the resources of the device class
+	nodeResources := v1.ResourceRequirements{Limits: v1.ResourceList{v1.ResourceMemory: resource.MustParse("8Gi")}}
+	c.spec.Storage.Nodes = []rookv1.Node{{Name: "node1", Resources: nodeResources}}
+	props = c.deviceClassOSDProps(osdProps, 0, "node1")
+	assert.Equal(t, "8Gi", props.resources.Limits.Memory().String())
+	assert.Equal(t, "2", props.resources.Limits.Cpu().String())
+	assert.Equal(t, 1, len(c.spec.Storage.Nodes[0].Resources.Limits))
+	c.spec.Storage.Nodes = nil
+
+	// the placement of the device class overrides the placement of the osds
+	props = c.deviceClassOSDProps(osdProps, 1, "node1")
+	assert.Equal(t, "nvme", props.crushDeviceClass)
+	assert.Equal(t, "2Gi", props.resources.Limits.Memory().String())
+	placement := c.nodeOSDPlacement(props.crushDeviceClass)
+	assert.Equal(t, "nvme", placement.Tolerations[0].Key)
+
+	// the device class of a new osd is the device class of its config
+	osdProps.storeConfig.DeviceClass = "hdd"
+	props = c.deviceClassOSDProps(osdProps, 2, "node1")
+	assert.Equal(t, "hdd", props.crushDeviceClass)
+	assert.Equal(t, "4Gi", props.resources.Limits.Memory().String())
+
+	// the node affinity of a device class is ignored by the osds on nodes
+	c.spec.Placement["osd-hdd"] = rookv1.Placement{NodeAffinity: &v1.NodeAffinity{}, Tolerations: []v1.Toleration{{Key: "hdd"}}}
+	placement = c.nodeOSDPlacement("hdd")
+	assert.Nil(t, placement.NodeAffinity)
+	assert.Equal(t, "hdd", placement.Tolerations[0].Key)
+	assert.NotNil(t, c.spec.Placement["osd-hdd"].NodeAffinity)
+
+	// the osds on pvcs get the placement of their device class but not its resources
+	setPlacement := rookv1.Placement{PodAntiAffinity: &v1.PodAntiAffinity{}, Tolerations: []v1.Toleration{{Key: "set"}}}
+	pvcProps := osdProperties{crushHostname: "set1-data-0", pvc: v1.PersistentVolumeClaimVolumeSource{ClaimName: "set1-data-0"}, placement: setPlacement, resources: osdResources}
+	props = c.deviceClassOSDProps(pvcProps, 0, "")
+	assert.Equal(t, "hdd", props.crushDeviceClass)
+	assert.NotNil(t, props.placement.NodeAffinity)
+	assert.NotNil(t, props.placement.PodAntiAffinity)
+	assert.Equal(t, "hdd", props.placement.Tolerations[0].Key)
+	assert.Equal(t, "2Gi", props.resources.Limits.Memory().String())
+	pvcProps.crushDeviceClass = "ssd"
+	props = c.deviceClassOSDProps(pvcProps, 3, "")
+	assert.Equal(t, "ssd", props.crushDeviceClass)
+	assert.Equal(t, "set", props.placement.Tolerations[0].Key)
+
+	// the device classes are not loaded without settings per device class
+	c.spec = cephv1.ClusterSpec{}
+	err = c.loadDeviceClasses()
+	assert.NoError(t, err)
+	assert.Equal(t, 0, len(c.deviceClasses))
+}
@@ -0,0 +1,64 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package osd
+
+import (
+	"sync"
+)
+
+const (
+	defaultProvisioningConcurrency = 1
+)
+
+// workerPool runs the provisioning work of the nodes and PVCs with a bounded concurrency. The
+// work of a node or PVC is always submitted as a single task, so the OSDs of a node are still
+// provisioned one after the other.
+type workerPool struct {
+	slots chan struct{}
+	wg    sync.WaitGroup
+}
+
+func newWorkerPool(concurrency int) *workerPool {
+	if concurrency < 1 {
+		concurrency = defaultProvisioningConcurrency
+	}
+	return &workerPool{slots: make(chan struct{}, concurrency)}
+}
+
+// run starts the task as soon as a worker is free, it blocks until then
+func (p *workerPool) run(task func()) {
+	p.slots <- struct{}{}
+	p.wg.Add(1)
+	go func() {
+		defer func() {
+			<-p.slots
+			p.wg.Done()
+		}()
+		task()
+	}()
+}
+
+// wait blocks until all the tasks submitted to the pool are done
+func (p *workerPool) wait() {
+	p.wg.Wait()
+}
+
+// newProvisioningPool returns a worker pool sized according to the provisioning concurrency of the
+// storage spec
+func (c *Cluster) newProvisioningPool() *workerPool {
+	return newWorkerPool(c.spec.Storage.ProvisioningConcurrency)
+}
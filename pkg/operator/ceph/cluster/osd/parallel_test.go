@@ -0,0 +1,151 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package osd
+
+import (
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/rook/rook/pkg/clusterd"
+	"github.com/rook/rook/pkg/daemon/ceph/client"
+	"github.com/stretchr/testify/assert"
+	apps "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestWorkerPool(t *testing.T) {
+	for _, concurrency := range []int{0, 1, 3} {
+		pool := newWorkerPool(concurrency)
+		expected := concurrency
+		if expected < 1 {
+			expected = defaultProvisioningConcurrency
+		}
+
+		// the tasks block until released so they all run at the same time as far as the pool allows
+		var mutex sync.Mutex
+		running, maxRunning, done := 0, 0, 0
+		started := make(chan struct{}, 10)
+		release := make(chan struct{})
+		submitted := make(chan struct{})
+		go func() {
+			for i := 0; i < 10; i++ {
+				pool.run(func() {
+					mutex.Lock()
+					running++
+					if running > maxRunning {
+						maxRunning = running
+					}
+					mutex.Unlock()
+					started <- struct{}{}
+
+					<-release
+					mutex.Lock()
+					running--
+					done++
+					mutex.Unlock()
+				})
+			}
+			close(submitted)
+		}()
+
+		// the pool starts as many tasks as its concurrency and no more
+		for i := 0; i < expected; i++ {
+			select {
+			case <-started:
+			case <-time.After(5 * time.Second):
+				t.Fatalf("only %d tasks started with concurrency %d", i, expected)
+			}
+		}
+		select {
+		case <-started:
+			t.Fatalf("more than %d tasks started", expected)
+		case <-time.After(100 * time.Millisecond):
+		}
+
+		close(release)
+		<-submitted
+		pool.wait()
+		assert.Equal(t, 10, done)
+		assert.Equal(t, expected, maxRunning)
+	}
+}
+
+func TestProvisionConfigConcurrentErrors(t *testing.T) {
+	config := &provisionConfig{}
+	pool := newWorkerPool(4)
+	for i := 0; i < 20; i++ {
+		pool.run(func() {
+			config.addError("failed to provision osd on node %q", "node1")
+		})
+	}
+	pool.wait()
+	assert.Equal(t, 20, len(config.errorMessages))
+}
+
+func TestUpdateOSDDeploymentsSerially(t *testing.T) {
+	// the existing deployments found by the parallel workers are only queued
+	config := &provisionConfig{}
+	pool := newWorkerPool(4)
+	for i := 0; i < 6; i++ {
+		id := i
+		pool.run(func() {
+			config.addDeploymentUpdate(id, &apps.Deployment{})
+		})
+	}
+	pool.wait()
+	assert.Equal(t, 6, len(config.deploymentUpdates))
+
+	var mutex sync.Mutex
+	running, maxRunning := 0, 0
+	updated := []string{}
+	oldUpdate := updateDeploymentAndWait
+	defer func() { updateDeploymentAndWait = oldUpdate }()
+	updateDeploymentAndWait = func(context *clusterd.Context, clusterInfo *client.ClusterInfo, deployment *apps.Deployment, daemonType, daemonName string, skipUpgradeChecks, continueUpgradeAfterChecksEvenIfNotHealthy bool) error {
+		mutex.Lock()
+		running++
+		if running > maxRunning {
+			maxRunning = running
+		}
+		updated = append(updated, daemonName)
+		mutex.Unlock()
+		time.Sleep(time.Millisecond)
+		mutex.Lock()
+		running--
+		mutex.Unlock()
+		return nil
+	}
+
+	// the osd being removed is not updated
+	removed := &apps.Deployment{ObjectMeta: metav1.ObjectMeta{Name: "rook-ceph-osd-6", Namespace: "ns", Labels: map[string]string{OSDRemovalLabelKey: "true"}}}
+	config.addDeploymentUpdate(6, removed)
+	clientset := fake.NewSimpleClientset(removed)
+
+	// the updates run one at a time and only once
+	c := &Cluster{context: &clusterd.Context{Clientset: clientset}, clusterInfo: client.AdminClusterInfo("ns")}
+	c.updateOSDDeployments(config)
+	assert.Equal(t, 1, maxRunning)
+	assert.Equal(t, 6, len(updated))
+	for i := 0; i < 6; i++ {
+		assert.Contains(t, updated, strconv.Itoa(i))
+	}
+	assert.NotContains(t, updated, "6")
+	c.updateOSDDeployments(config)
+	assert.Equal(t, 6, len(updated))
+}
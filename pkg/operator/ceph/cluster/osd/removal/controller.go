@@ -0,0 +1,228 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package removal to remove OSDs from the cluster
+package removal
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/coreos/pkg/capnslog"
+	"github.com/pkg/errors"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/clusterd"
+	"github.com/rook/rook/pkg/operator/ceph/cluster/mon"
+	opcontroller "github.com/rook/rook/pkg/operator/ceph/controller"
+	"github.com/rook/rook/pkg/operator/k8sutil"
+
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+const (
+	controllerName = "ceph-osd-removal-controller"
+)
+
+var logger = capnslog.NewPackageLogger("github.com/rook/rook", controllerName)
+
+var cephOSDRemovalKind = reflect.TypeOf(cephv1.CephOSDRemoval{}).Name()
+
+// Sets the type meta for the controller main object
+var controllerTypeMeta = metav1.TypeMeta{
+	Kind:       cephOSDRemovalKind,
+	APIVersion: fmt.Sprintf("%s/%s", cephv1.CustomResourceGroup, cephv1.Version),
+}
+
+var (
+	// drainCheckInterval is the interval to check whether the data was drained from the OSDs
+	drainCheckInterval = 30 * time.Second
+)
+
+// ReconcileCephOSDRemoval reconciles a cephOSDRemoval object
+type ReconcileCephOSDRemoval struct {
+	context *clusterd.Context
+	client  client.Client
+	scheme  *runtime.Scheme
+}
+
+// Add creates a new cephOSDRemoval Controller and adds it to the Manager. The Manager will set fields on the Controller
+// and Start it when the Manager is Started.
+func Add(mgr manager.Manager, context *clusterd.Context) error {
+	return add(mgr, newReconciler(mgr, context), context)
+}
+
+// newReconciler returns a new reconcile.Reconciler
+func newReconciler(mgr manager.Manager, context *clusterd.Context) reconcile.Reconciler {
+	// Add the cephv1 scheme to the manager scheme so that the controller knows about it
+	mgrScheme := mgr.GetScheme()
+	if err := cephv1.AddToScheme(mgr.GetScheme()); err != nil {
+		panic(err)
+	}
+	return &ReconcileCephOSDRemoval{
+		client:  mgr.GetClient(),
+		scheme:  mgrScheme,
+		context: context,
+	}
+}
+
+func add(mgr manager.Manager, r reconcile.Reconciler, context *clusterd.Context) error {
+	// Create a new controller
+	c, err := controller.New(controllerName, mgr, opcontroller.ControllerOptions(context, controllerName, r))
+	if err != nil {
+		return err
+	}
+
+	// Watch for changes on the cephOSDRemoval CRD object
+	err = c.Watch(&source.Kind{Type: &cephv1.CephOSDRemoval{TypeMeta: controllerTypeMeta}}, &handler.EnqueueRequestForObject{}, opcontroller.WatchControllerPredicate())
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Reconcile reads that state of the cluster for a cephOSDRemoval object and makes changes based on the state read
+// and what is in the cephOSDRemoval.Spec
+// The Controller will requeue the Request to be processed again if the returned error is non-nil or
+// Result.Requeue is true, otherwise upon completion it will remove the work from the queue.
+func (r *ReconcileCephOSDRemoval) Reconcile(request reconcile.Request) (reconcile.Result, error) {
+	// workaround because the rook logging mechanism is not compatible with the controller-runtime loggin interface
+	reconcileResponse, err := r.reconcile(request)
+	if err != nil {
+		logger.Errorf("failed to reconcile %v", err)
+	}
+
+	return reconcileResponse, err
+}
+
+func (r *ReconcileCephOSDRemoval) reconcile(request reconcile.Request) (reconcile.Result, error) {
+	// Fetch the cephOSDRemoval instance
+	cephOSDRemoval := &cephv1.CephOSDRemoval{}
+	err := r.client.Get(context.TODO(), request.NamespacedName, cephOSDRemoval)
+	if err != nil {
+		if kerrors.IsNotFound(err) {
+			logger.Debug("cephOSDRemoval resource not found. Ignoring since object must be deleted.")
+			return reconcile.Result{}, nil
+		}
+		// Error reading the object - requeue the request.
+		return reconcile.Result{}, errors.Wrap(err, "failed to get cephOSDRemoval")
+	}
+
+	// Do nothing while the reconcile is paused
+	if opcontroller.IsReconcilePaused(cephOSDRemoval) {
+		logger.Infof("reconcile of CephOSDRemoval %q is paused with the %q annotation, skipping", request.NamespacedName, opcontroller.PauseReconcileAnnotation)
+		return reconcile.Result{}, nil
+	}
+
+	// The removal is only done once
+	if cephOSDRemoval.Status != nil && (cephOSDRemoval.Status.Phase == CompletedPhase || cephOSDRemoval.Status.Phase == k8sutil.FailedStatus) {
+		logger.Debugf("removal %q is %s, nothing to do", request.NamespacedName, cephOSDRemoval.Status.Phase)
+		return reconcile.Result{}, nil
+	}
+
+	// Make sure a CephCluster is present otherwise do nothing
+	_, isReadyToReconcile, _, reconcileResponse := opcontroller.IsReadyToReconcile(r.client, r.context, request.NamespacedName, controllerName)
+	if !isReadyToReconcile {
+		logger.Debugf("CephCluster resource not ready in namespace %q, retrying in %q.", request.NamespacedName.Namespace, reconcileResponse.RequeueAfter.String())
+		return reconcileResponse, nil
+	}
+
+	// Populate clusterInfo
+	// Always populate it during each reconcile
+	clusterInfo, _, _, err := mon.LoadClusterInfo(r.context, request.NamespacedName.Namespace)
+	if err != nil {
+		return reconcile.Result{}, errors.Wrap(err, "failed to populate cluster info")
+	}
+
+	// The OSDs to remove are resolved once so that the OSDs of the node are still known once
+	// their deployments are deleted
+	if cephOSDRemoval.Status == nil || len(cephOSDRemoval.Status.OSDs) == 0 {
+		osdIDs, err := osdsToRemove(r.context, clusterInfo, cephOSDRemoval.Spec)
+		if err != nil {
+			if isInvalidRemoval(err) {
+				return r.setFailedStatus(cephOSDRemoval, "failed to find the osds to remove", err)
+			}
+			// the osds are looked up again
+			return reconcile.Result{}, errors.Wrap(err, "failed to find the osds to remove")
+		}
+		cephOSDRemoval.Status = &cephv1.OSDRemovalStatus{Phase: DrainingPhase}
+		for _, id := range osdIDs {
+			cephOSDRemoval.Status.OSDs = append(cephOSDRemoval.Status.OSDs, cephv1.OSDRemovalState{ID: id, State: PendingState})
+		}
+	}
+
+	// The progress made so far is saved in the status even if the removal failed, the removal is
+	// retried from there
+	done, removeErr := removeOSDs(r.context, clusterInfo, cephOSDRemoval.Status)
+	if removeErr != nil {
+		cephOSDRemoval.Status.Message = fmt.Sprintf("failed to remove osds. %v", removeErr)
+	}
+	if err := opcontroller.UpdateStatus(r.client, cephOSDRemoval); err != nil {
+		return reconcile.Result{}, errors.Wrapf(err, "failed to update the status of removal %q", request.NamespacedName)
+	}
+	if removeErr != nil {
+		return reconcile.Result{}, errors.Wrap(removeErr, "failed to remove osds")
+	}
+	if !done {
+		logger.Infof("removal %q: %s", request.NamespacedName, cephOSDRemoval.Status.Message)
+		return reconcile.Result{Requeue: true, RequeueAfter: drainCheckInterval}, nil
+	}
+
+	logger.Infof("done removing osds for %q", request.NamespacedName)
+	return reconcile.Result{}, nil
+}
+
+func (r *ReconcileCephOSDRemoval) setFailedStatus(cephOSDRemoval *cephv1.CephOSDRemoval, errMessage string, err error) (reconcile.Result, error) {
+	updateStatus(r.client, types.NamespacedName{Namespace: cephOSDRemoval.Namespace, Name: cephOSDRemoval.Name}, k8sutil.FailedStatus, fmt.Sprintf("%s. %v", errMessage, err))
+	return reconcile.Result{}, errors.Wrapf(err, "%s", errMessage)
+}
+
+// updateStatus updates an object with a given status
+func updateStatus(client client.Client, name types.NamespacedName, phase, message string) {
+	removal := &cephv1.CephOSDRemoval{}
+	err := client.Get(context.TODO(), name, removal)
+	if err != nil {
+		if kerrors.IsNotFound(err) {
+			logger.Debug("CephOSDRemoval resource not found. Ignoring since object must be deleted.")
+			return
+		}
+		logger.Warningf("failed to retrieve osd removal %q to update status to %q. %v", name, phase, err)
+		return
+	}
+
+	if removal.Status == nil {
+		removal.Status = &cephv1.OSDRemovalStatus{}
+	}
+
+	removal.Status.Phase = phase
+	removal.Status.Message = message
+	if err := opcontroller.UpdateStatus(client, removal); err != nil {
+		logger.Errorf("failed to set osd removal %q status to %q. %v", removal.Name, phase, err)
+		return
+	}
+	logger.Debugf("osd removal %q status updated to %q", name, phase)
+}
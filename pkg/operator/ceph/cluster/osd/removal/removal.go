@@ -0,0 +1,282 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package removal
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/clusterd"
+	cephclient "github.com/rook/rook/pkg/daemon/ceph/client"
+	"github.com/rook/rook/pkg/operator/ceph/cluster/osd"
+	"github.com/rook/rook/pkg/operator/k8sutil"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	// DrainingPhase is the phase of the removal until all the OSDs are purged
+	DrainingPhase = "Draining"
+	// CompletedPhase is the phase of the removal once all the OSDs are purged
+	CompletedPhase = "Completed"
+
+	// PendingState is the state of an OSD not marked out yet
+	PendingState = "Pending"
+	// DrainingState is the state of an OSD marked out while its data is moved to the other OSDs
+	DrainingState = "Draining"
+	// StoppedState is the state of an OSD whose daemon was stopped, until it is safe to destroy. The
+	// deployment of the OSD is scaled down and marked so the cluster orchestration doesn't start it
+	// again before it is purged.
+	StoppedState = "Stopped"
+	// PurgedState is the state of an OSD removed from the cluster
+	PurgedState = "Purged"
+)
+
+// invalidRemovalError is returned when the spec of the removal cannot be satisfied, the removal is
+// failed rather than retried
+type invalidRemovalError struct {
+	err error
+}
+
+func (e *invalidRemovalError) Error() string {
+	return e.err.Error()
+}
+
+// isInvalidRemoval returns whether the error is about the spec of the removal
+func isInvalidRemoval(err error) bool {
+	_, ok := errors.Cause(err).(*invalidRemovalError)
+	return ok
+}
+
+// osdsToRemove returns the sorted IDs of the OSDs of the spec and of the OSDs running on the node
+// of the spec. An error is returned if an OSD doesn't exist.
+func osdsToRemove(context *clusterd.Context, clusterInfo *cephclient.ClusterInfo, spec cephv1.OSDRemovalSpec) ([]int, error) {
+	osdDump, err := cephclient.GetOSDDump(context, clusterInfo)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get osd dump")
+	}
+
+	osdIDs := map[int]struct{}{}
+	for _, id := range spec.OSDIDs {
+		if _, _, err := osdDump.StatusByID(int64(id)); err != nil {
+			return nil, &invalidRemovalError{errors.Wrapf(err, "cannot remove osd.%d", id)}
+		}
+		osdIDs[id] = struct{}{}
+	}
+	if spec.NodeName != "" {
+		nodeOSDs, err := osdsOnNode(context.Clientset, clusterInfo.Namespace, spec.NodeName)
+		if err != nil {
+			return nil, err
+		}
+		if len(nodeOSDs) == 0 {
+			return nil, &invalidRemovalError{errors.Errorf("no osd found on node %q", spec.NodeName)}
+		}
+		for _, id := range nodeOSDs {
+			osdIDs[id] = struct{}{}
+		}
+	}
+	if len(osdIDs) == 0 {
+		return nil, &invalidRemovalError{errors.New("no osd to remove, the osd ids or the node name must be set")}
+	}
+
+	result := []int{}
+	for id := range osdIDs {
+		result = append(result, id)
+	}
+	sort.Ints(result)
+	return result, nil
+}
+
+// osdsOnNode returns the IDs of the OSDs running on the given node. The deployments of the OSDs on
+// the devices of the nodes and of the OSDs on non-portable PVCs select their node. The portable OSDs
+// on PVCs can move to another node with their PVC, they are on the node their pod is scheduled on.
+func osdsOnNode(clientset kubernetes.Interface, namespace, nodeName string) ([]int, error) {
+	listOpts := metav1.ListOptions{LabelSelector: fmt.Sprintf("%s=%s", k8sutil.AppAttr, osd.AppName)}
+	deployments, err := clientset.AppsV1().Deployments(namespace).List(listOpts)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list osd deployments")
+	}
+	pods, err := clientset.CoreV1().Pods(namespace).List(listOpts)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list osd pods")
+	}
+	scheduledOnNode := map[string]bool{}
+	for _, pod := range pods.Items {
+		if pod.Spec.NodeName == nodeName {
+			scheduledOnNode[pod.Labels[osd.OsdIdLabelKey]] = true
+		}
+	}
+
+	osdIDs := []int{}
+	for _, d := range deployments.Items {
+		node, ok := d.Spec.Template.Spec.NodeSelector[v1.LabelHostname]
+		if ok && node != nodeName {
+			continue
+		}
+		if !ok && !scheduledOnNode[d.Labels[osd.OsdIdLabelKey]] {
+			continue
+		}
+		id, err := strconv.Atoi(d.Labels[osd.OsdIdLabelKey])
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to parse the osd id of deployment %q", d.Name)
+		}
+		osdIDs = append(osdIDs, id)
+	}
+	return osdIDs, nil
+}
+
+// removeOSDs moves the OSDs of the status through the removal steps and returns whether they are
+// all purged. The OSDs are first marked out so their data is moved to the other OSDs. Once the
+// data is moved and all the PGs are clean, the OSD daemons are stopped and each OSD is purged as
+// soon as it is safe to destroy. The state of the OSDs is updated in the status so the removal
+// resumes from there the next time.
+func removeOSDs(context *clusterd.Context, clusterInfo *cephclient.ClusterInfo, status *cephv1.OSDRemovalStatus) (bool, error) {
+	for i := range status.OSDs {
+		removal := &status.OSDs[i]
+		if removal.State != PendingState {
+			continue
+		}
+		logger.Infof("marking osd.%d out", removal.ID)
+		if output, err := cephclient.OSDOut(context, clusterInfo, removal.ID); err != nil {
+			return false, errors.Wrapf(err, "failed to mark osd.%d out. %s", removal.ID, output)
+		}
+		removal.State = DrainingState
+	}
+
+	// the data of all the osds is drained before any of them is stopped
+	if countOSDs(status, DrainingState) > 0 {
+		cephStatus, err := cephclient.Status(context, clusterInfo)
+		if err != nil {
+			return false, errors.Wrap(err, "failed to get the ceph status")
+		}
+		status.CleanPGs, status.TotalPGs = pgProgress(cephStatus)
+		if status.CleanPGs < status.TotalPGs {
+			status.Phase = DrainingPhase
+			status.Message = fmt.Sprintf("waiting for the data to be drained from %d osd(s), %d/%d pgs are clean", countOSDs(status, DrainingState), status.CleanPGs, status.TotalPGs)
+			return false, nil
+		}
+
+		for i := range status.OSDs {
+			removal := &status.OSDs[i]
+			if removal.State != DrainingState {
+				continue
+			}
+			logger.Infof("stopping osd.%d", removal.ID)
+			if err := stopOSDDeployment(context, clusterInfo.Namespace, removal.ID); err != nil {
+				return false, err
+			}
+			removal.State = StoppedState
+		}
+	}
+
+	for i := range status.OSDs {
+		removal := &status.OSDs[i]
+		if removal.State != StoppedState {
+			continue
+		}
+		// safe-to-destroy fails while the osd is still up or its PGs are not durably stored elsewhere
+		safe, err := cephclient.OsdSafeToDestroy(context, clusterInfo, removal.ID)
+		if err != nil || !safe {
+			logger.Infof("osd.%d is not safe to destroy yet. %v", removal.ID, err)
+			continue
+		}
+		logger.Infof("purging osd.%d", removal.ID)
+		if err := cephclient.PurgeOSD(context, clusterInfo, removal.ID); err != nil {
+			return false, err
+		}
+		if err := deleteOSDDeployment(context, clusterInfo.Namespace, removal.ID); err != nil {
+			return false, err
+		}
+		removal.State = PurgedState
+	}
+
+	if stopped := countOSDs(status, StoppedState); stopped > 0 {
+		status.Phase = DrainingPhase
+		status.Message = fmt.Sprintf("waiting for %d stopped osd(s) to be safe to destroy", stopped)
+		return false, nil
+	}
+	status.Phase = CompletedPhase
+	status.Message = fmt.Sprintf("purged %d osd(s)", len(status.OSDs))
+	return true, nil
+}
+
+// stopOSDDeployment scales down the deployment of the OSD if it exists and marks it with the removal
+// label so the cluster orchestration doesn't scale it up again
+func stopOSDDeployment(context *clusterd.Context, namespace string, osdID int) error {
+	label := fmt.Sprintf("%s=%d", osd.OsdIdLabelKey, osdID)
+	deployments, err := k8sutil.GetDeployments(context.Clientset, namespace, label)
+	if err != nil {
+		return errors.Wrapf(err, "failed to get the deployment of osd.%d", osdID)
+	}
+	for i := range deployments.Items {
+		d := &deployments.Items[i]
+		k8sutil.AddLabelToDeployment(osd.OSDRemovalLabelKey, "true", d)
+		replicas := int32(0)
+		d.Spec.Replicas = &replicas
+		if _, err := context.Clientset.AppsV1().Deployments(namespace).Update(d); err != nil {
+			return errors.Wrapf(err, "failed to scale down the deployment of osd.%d", osdID)
+		}
+	}
+	return nil
+}
+
+// deleteOSDDeployment deletes the deployment of the OSD if it exists once the OSD is purged. An OSD carved out of a PVC
+// with other OSDs is removed from the OSDs of the PVC so it is not started again.
+func deleteOSDDeployment(context *clusterd.Context, namespace string, osdID int) error {
+	label := fmt.Sprintf("%s=%d", osd.OsdIdLabelKey, osdID)
+	deployments, err := k8sutil.GetDeployments(context.Clientset, namespace, label)
+	if err != nil {
+		return errors.Wrapf(err, "failed to get the deployment of osd.%d", osdID)
+	}
+	for _, d := range deployments.Items {
+		if pvcName, ok := d.Labels[osd.OSDOverPVCLabelKey]; ok {
+			if err := osd.RemoveOSDFromPVC(context, namespace, pvcName, osdID); err != nil {
+				return errors.Wrapf(err, "failed to remove osd.%d from pvc %q", osdID, pvcName)
+			}
+		}
+		if err := k8sutil.DeleteDeployment(context.Clientset, namespace, d.Name); err != nil {
+			return errors.Wrapf(err, "failed to delete the deployment of osd.%d", osdID)
+		}
+	}
+	return nil
+}
+
+func countOSDs(status *cephv1.OSDRemovalStatus, state string) int {
+	count := 0
+	for _, removal := range status.OSDs {
+		if removal.State == state {
+			count++
+		}
+	}
+	return count
+}
+
+// pgProgress returns the number of clean PGs and the total number of PGs
+func pgProgress(status cephclient.CephStatus) (int, int) {
+	clean := 0
+	for _, pg := range status.PgMap.PgsByState {
+		if strings.HasPrefix(pg.StateName, "active+clean") {
+			clean += pg.Count
+		}
+	}
+	return clean, status.PgMap.NumPgs
+}
@@ -0,0 +1,191 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package removal
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/pkg/errors"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/clusterd"
+	cephclient "github.com/rook/rook/pkg/daemon/ceph/client"
+	"github.com/rook/rook/pkg/operator/ceph/cluster/osd"
+	"github.com/rook/rook/pkg/operator/k8sutil"
+	exectest "github.com/rook/rook/pkg/util/exec/test"
+	"github.com/stretchr/testify/assert"
+	apps "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func newTestOSDDeployment(id int, nodeName string) *apps.Deployment {
+	d := &apps.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      fmt.Sprintf("rook-ceph-osd-%d", id),
+			Namespace: "ns",
+			Labels:    map[string]string{k8sutil.AppAttr: osd.AppName, osd.OsdIdLabelKey: fmt.Sprintf("%d", id)},
+		},
+	}
+	d.Spec.Template.Spec.NodeSelector = map[string]string{v1.LabelHostname: nodeName}
+	return d
+}
+
+func TestOSDsToRemove(t *testing.T) {
+	clientset := fake.NewSimpleClientset(
+		newTestOSDDeployment(0, "node1"),
+		newTestOSDDeployment(1, "node2"),
+		newTestOSDDeployment(2, "node2"),
+	)
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutputFile: func(command string, outFileArg string, args ...string) (string, error) {
+			if args[0] == "osd" && args[1] == "dump" {
+				return `{"OSDs": [{"OSD": 0, "Up": 1, "In": 1}, {"OSD": 1, "Up": 1, "In": 1}, {"OSD": 2, "Up": 1, "In": 1}]}`, nil
+			}
+			return "", nil
+		},
+	}
+	context := &clusterd.Context{Clientset: clientset, Executor: executor}
+	clusterInfo := cephclient.AdminClusterInfo("ns")
+
+	ids, err := osdsToRemove(context, clusterInfo, cephv1.OSDRemovalSpec{OSDIDs: []int{2, 0}})
+	assert.NoError(t, err)
+	assert.Equal(t, []int{0, 2}, ids)
+
+	// the osds of the node are added without duplicates
+	ids, err = osdsToRemove(context, clusterInfo, cephv1.OSDRemovalSpec{OSDIDs: []int{1}, NodeName: "node2"})
+	assert.NoError(t, err)
+	assert.Equal(t, []int{1, 2}, ids)
+
+	// unknown osd
+	_, err = osdsToRemove(context, clusterInfo, cephv1.OSDRemovalSpec{OSDIDs: []int{3}})
+	assert.Error(t, err)
+
+	// no osd on the node
+	_, err = osdsToRemove(context, clusterInfo, cephv1.OSDRemovalSpec{NodeName: "node3"})
+	assert.Error(t, err)
+
+	// nothing to remove
+	_, err = osdsToRemove(context, clusterInfo, cephv1.OSDRemovalSpec{})
+	assert.Error(t, err)
+	assert.True(t, isInvalidRemoval(err))
+
+	// the osd dump fails, the osds are looked up again
+	executor.MockExecuteCommandWithOutputFile = func(command string, outFileArg string, args ...string) (string, error) {
+		return "", errors.New("timed out")
+	}
+	_, err = osdsToRemove(context, clusterInfo, cephv1.OSDRemovalSpec{OSDIDs: []int{1}})
+	assert.Error(t, err)
+	assert.False(t, isInvalidRemoval(err))
+}
+
+func TestOSDsOnNode(t *testing.T) {
+	portable := newTestOSDDeployment(3, "")
+	portable.Spec.Template.Spec.NodeSelector = nil
+	pod := &v1.Pod{ObjectMeta: metav1.ObjectMeta{
+		Name:      "rook-ceph-osd-3-abc",
+		Namespace: "ns",
+		Labels:    map[string]string{k8sutil.AppAttr: osd.AppName, osd.OsdIdLabelKey: "3"},
+	}}
+	pod.Spec.NodeName = "node1"
+	clientset := fake.NewSimpleClientset(newTestOSDDeployment(0, "node1"), newTestOSDDeployment(1, "node2"), portable, pod)
+
+	// the portable osd on a pvc is on the node of its pod
+	ids, err := osdsOnNode(clientset, "ns", "node1")
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []int{0, 3}, ids)
+
+	ids, err = osdsOnNode(clientset, "ns", "node2")
+	assert.NoError(t, err)
+	assert.Equal(t, []int{1}, ids)
+}
+
+func TestRemoveOSDs(t *testing.T) {
+	clientset := fake.NewSimpleClientset(newTestOSDDeployment(0, "node1"), newTestOSDDeployment(1, "node1"))
+	clean := false
+	safeToDestroy := false
+	outOSDs := []string{}
+	purgedOSDs := []string{}
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutputFile: func(command string, outFileArg string, args ...string) (string, error) {
+			switch {
+			case args[0] == "status":
+				if clean {
+					return `{"pgmap":{"pgs_by_state":[{"state_name":"active+clean","count":100}],"num_pgs":100}}`, nil
+				}
+				return `{"pgmap":{"pgs_by_state":[{"state_name":"active+clean","count":60},{"state_name":"active+remapped+backfilling","count":40}],"num_pgs":100}}`, nil
+			case args[0] == "osd" && args[1] == "out":
+				outOSDs = append(outOSDs, args[2])
+			case args[0] == "osd" && args[1] == "safe-to-destroy":
+				if safeToDestroy {
+					return fmt.Sprintf(`{"safe_to_destroy":[%s],"active":[],"missing_stats":[],"stored_pgs":[]}`, args[2]), nil
+				}
+				return `{"safe_to_destroy":[],"active":[],"missing_stats":[],"stored_pgs":[]}`, nil
+			case args[0] == "osd" && args[1] == "purge":
+				purgedOSDs = append(purgedOSDs, args[2])
+			}
+			return "", nil
+		},
+	}
+	context := &clusterd.Context{Clientset: clientset, Executor: executor}
+	clusterInfo := cephclient.AdminClusterInfo("ns")
+	status := &cephv1.OSDRemovalStatus{OSDs: []cephv1.OSDRemovalState{{ID: 0, State: PendingState}, {ID: 1, State: PendingState}}}
+
+	// the osds are marked out and the data is being drained
+	done, err := removeOSDs(context, clusterInfo, status)
+	assert.NoError(t, err)
+	assert.False(t, done)
+	assert.Equal(t, []string{"0", "1"}, outOSDs)
+	assert.Equal(t, DrainingPhase, status.Phase)
+	assert.Equal(t, 60, status.CleanPGs)
+	assert.Equal(t, 100, status.TotalPGs)
+	assert.Equal(t, 2, countOSDs(status, DrainingState))
+
+	// the osds are not marked out again
+	done, err = removeOSDs(context, clusterInfo, status)
+	assert.NoError(t, err)
+	assert.False(t, done)
+	assert.Equal(t, 2, len(outOSDs))
+
+	// the data is drained, the osds are stopped
+	clean = true
+	done, err = removeOSDs(context, clusterInfo, status)
+	assert.NoError(t, err)
+	assert.False(t, done)
+	assert.Equal(t, 2, countOSDs(status, StoppedState))
+	deployments, err := clientset.AppsV1().Deployments("ns").List(metav1.ListOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(deployments.Items))
+	for _, d := range deployments.Items {
+		assert.Equal(t, int32(0), *d.Spec.Replicas)
+		assert.Equal(t, "true", d.Labels[osd.OSDRemovalLabelKey])
+	}
+	assert.Equal(t, 0, len(purgedOSDs))
+
+	// the osds are safe to destroy
+	safeToDestroy = true
+	done, err = removeOSDs(context, clusterInfo, status)
+	assert.NoError(t, err)
+	assert.True(t, done)
+	assert.Equal(t, []string{"0", "1"}, purgedOSDs)
+	deployments, err = clientset.AppsV1().Deployments("ns").List(metav1.ListOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, 0, len(deployments.Items))
+	assert.Equal(t, CompletedPhase, status.Phase)
+	assert.Equal(t, 2, countOSDs(status, PurgedState))
+}
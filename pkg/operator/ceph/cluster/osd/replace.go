@@ -0,0 +1,134 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package osd
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/pkg/errors"
+	"github.com/rook/rook/pkg/daemon/ceph/client"
+	"github.com/rook/rook/pkg/operator/k8sutil"
+	apps "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// the device backing the osd is recorded on the osd deployment to detect when it is swapped
+	devicePathAnnotation = "ceph.rook.io/device-path"
+	deviceIDAnnotation   = "ceph.rook.io/device-id"
+)
+
+func setDeviceIdentityAnnotations(objectMeta *metav1.ObjectMeta, osd OSDInfo) {
+	if osd.DevicePath == "" || osd.DeviceID == "" {
+		return
+	}
+	if objectMeta.Annotations == nil {
+		objectMeta.Annotations = map[string]string{}
+	}
+	objectMeta.Annotations[devicePathAnnotation] = osd.DevicePath
+	objectMeta.Annotations[deviceIDAnnotation] = osd.DeviceID
+}
+
+// swappedOSDs returns the IDs of the osds of the deployments whose device was replaced by the
+// device of one of the given osds, the osds reported on the node. The device was replaced when a
+// new osd is on the same device path but the identity of the device is different. Since the device
+// paths are not stable across reboots, an osd whose device or id is still reported on the node, maybe
+// under another path, is never considered as swapped.
+func swappedOSDs(deployments []*apps.Deployment, osds []OSDInfo) []int {
+	reportedDevices := map[string]bool{}
+	reportedOSDs := map[int]bool{}
+	for _, osd := range osds {
+		if osd.DeviceID != "" {
+			reportedDevices[osd.DeviceID] = true
+		}
+		reportedOSDs[osd.ID] = true
+	}
+
+	swapped := []int{}
+	for _, d := range deployments {
+		devicePath := d.Annotations[devicePathAnnotation]
+		deviceID := d.Annotations[deviceIDAnnotation]
+		if devicePath == "" || deviceID == "" || reportedDevices[deviceID] {
+			continue
+		}
+		id, err := strconv.Atoi(d.Labels[OsdIdLabelKey])
+		if err != nil {
+			logger.Warningf("failed to parse the osd id of deployment %q. %v", d.Name, err)
+			continue
+		}
+		if reportedOSDs[id] {
+			continue
+		}
+		for _, osd := range osds {
+			if osd.ID != id && osd.DevicePath == devicePath && osd.DeviceID != "" && osd.DeviceID != deviceID {
+				logger.Infof("device %q of osd.%d was swapped for device %q of osd.%d", deviceID, id, osd.DeviceID, osd.ID)
+				swapped = append(swapped, id)
+				break
+			}
+		}
+	}
+	return swapped
+}
+
+// replaceSwappedOSDs purges the osds of the node whose device was physically replaced by a new
+// device on which the prepare job provisioned a new osd. Only the osds that are down and safe to
+// destroy are purged, an osd is down for a while after a reboot of the node.
+func (c *Cluster) replaceSwappedOSDs(nodeName string, osds []OSDInfo) error {
+	discoveredNodes, err := c.discoverStorageNodes()
+	if err != nil {
+		return err
+	}
+	swapped := swappedOSDs(discoveredNodes[nodeName], osds)
+	if len(swapped) == 0 {
+		return nil
+	}
+
+	osdDump, err := client.GetOSDDump(c.context, c.clusterInfo)
+	if err != nil {
+		return errors.Wrap(err, "failed to get osd dump")
+	}
+	for _, id := range swapped {
+		status, _, err := osdDump.StatusByID(int64(id))
+		if err != nil {
+			logger.Warningf("not replacing osd.%d. %v", id, err)
+			continue
+		}
+		if status == upStatus {
+			logger.Warningf("not replacing osd.%d on node %q since it is still up", id, nodeName)
+			continue
+		}
+		safe, err := client.OsdSafeToDestroy(c.context, c.clusterInfo, id)
+		if err != nil {
+			logger.Warningf("not replacing osd.%d on node %q. failed to check if it is safe to destroy. %v", id, nodeName, err)
+			continue
+		}
+		if !safe {
+			logger.Warningf("not replacing osd.%d on node %q since it is not safe to destroy yet", id, nodeName)
+			continue
+		}
+
+		logger.Infof("replacing osd.%d on node %q since its device was swapped", id, nodeName)
+		if err := client.PurgeOSD(c.context, c.clusterInfo, id); err != nil {
+			return err
+		}
+		if err := k8sutil.DeleteDeployment(c.context.Clientset, c.clusterInfo.Namespace, fmt.Sprintf(osdAppNameFmt, id)); err != nil {
+			return errors.Wrapf(err, "failed to delete the deployment of replaced osd.%d", id)
+		}
+	}
+	return nil
+}
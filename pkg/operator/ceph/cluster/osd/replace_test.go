@@ -0,0 +1,81 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package osd
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	apps "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestSetDeviceIdentityAnnotations(t *testing.T) {
+	objectMeta := metav1.ObjectMeta{}
+	setDeviceIdentityAnnotations(&objectMeta, OSDInfo{ID: 0})
+	assert.Nil(t, objectMeta.Annotations)
+
+	setDeviceIdentityAnnotations(&objectMeta, OSDInfo{ID: 0, DevicePath: "/dev/sdb", DeviceID: "serial1"})
+	assert.Equal(t, "/dev/sdb", objectMeta.Annotations[devicePathAnnotation])
+	assert.Equal(t, "serial1", objectMeta.Annotations[deviceIDAnnotation])
+}
+
+func TestSwappedOSDs(t *testing.T) {
+	newDeployment := func(id, devicePath, deviceID string) *apps.Deployment {
+		d := &apps.Deployment{ObjectMeta: metav1.ObjectMeta{
+			Name:   "rook-ceph-osd-" + id,
+			Labels: map[string]string{OsdIdLabelKey: id},
+		}}
+		setDeviceIdentityAnnotations(&d.ObjectMeta, OSDInfo{DevicePath: devicePath, DeviceID: deviceID})
+		return d
+	}
+	deployments := []*apps.Deployment{
+		newDeployment("0", "/dev/sdb", "serial1"),
+		newDeployment("1", "/dev/sdc", "serial2"),
+		newDeployment("2", "", ""),
+	}
+
+	// the prepare job reports the existing osds
+	osds := []OSDInfo{
+		{ID: 0, DevicePath: "/dev/sdb", DeviceID: "serial1"},
+		{ID: 1, DevicePath: "/dev/sdc", DeviceID: "serial2"},
+	}
+	assert.Equal(t, []int{}, swappedOSDs(deployments, osds))
+
+	// the device of osd 1 was swapped and a new osd was created on it
+	osds[1] = OSDInfo{ID: 3, DevicePath: "/dev/sdc", DeviceID: "serial3"}
+	assert.Equal(t, []int{1}, swappedOSDs(deployments, osds))
+
+	// a new osd without a known identity is not a replacement
+	osds[1] = OSDInfo{ID: 3, DevicePath: "/dev/sdc"}
+	assert.Equal(t, []int{}, swappedOSDs(deployments, osds))
+
+	// the devices of osd 0 and 1 swapped their names after a reboot
+	osds = []OSDInfo{
+		{ID: 0, DevicePath: "/dev/sdc", DeviceID: "serial1"},
+		{ID: 1, DevicePath: "/dev/sdb", DeviceID: "serial2"},
+	}
+	assert.Equal(t, []int{}, swappedOSDs(deployments, osds))
+
+	// the device of osd 1 is still on the node under another name next to a new osd
+	osds = []OSDInfo{
+		{ID: 0, DevicePath: "/dev/sdb", DeviceID: "serial1"},
+		{ID: 3, DevicePath: "/dev/sdc", DeviceID: "serial3"},
+		{ID: 1, DevicePath: "/dev/sdd", DeviceID: "serial2"},
+	}
+	assert.Equal(t, []int{}, swappedOSDs(deployments, osds))
+}
@@ -0,0 +1,196 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package osd
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/pkg/errors"
+	"github.com/rook/rook/pkg/daemon/ceph/client"
+	"github.com/rook/rook/pkg/operator/k8sutil"
+	apps "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// osdSizeAnnotation records on the pvc of an osd the size the osd was expanded to
+	osdSizeAnnotation = "ceph.rook.io/osd-size"
+	// osdWeightSizeAnnotation records on the pvc of an osd the size the crush weight of the osd was
+	// set for, the reweight is retried on its own until it matches the size of the osd
+	osdWeightSizeAnnotation = "ceph.rook.io/osd-weight-size"
+	// the crush weights are expressed in TiB
+	crushWeightUnit = 1 << 40
+)
+
+// checkOSDResize expands the osds on pvcs that grew. The bluestore device of an osd on a pvc is
+// expanded by the expand-bluefs init container when the osd restarts, so the osd is restarted and
+// its crush weight is updated to the new size of the pvc once it runs again. The health check does
+// not wait for the osd to restart, the crush weight is updated by one of the next checks. The pvcs
+// holding several osds are not expanded, and neither are the osds on the devices of the nodes.
+func (m *OSDHealthMonitor) checkOSDResize() error {
+	selector := fmt.Sprintf("%s=%s,%s", k8sutil.AppAttr, AppName, OSDOverPVCLabelKey)
+	deployments, err := m.context.Clientset.AppsV1().Deployments(m.clusterInfo.Namespace).List(metav1.ListOptions{LabelSelector: selector})
+	if err != nil {
+		return errors.Wrap(err, "failed to list the osd deployments on pvcs")
+	}
+	// the lvs of the osds carved out of the same pvc don't grow with the pvc
+	pvcOSDs := map[string]int{}
+	for _, d := range deployments.Items {
+		pvcOSDs[d.Labels[OSDOverPVCLabelKey]]++
+	}
+	for i := range deployments.Items {
+		if pvcName := deployments.Items[i].Labels[OSDOverPVCLabelKey]; pvcOSDs[pvcName] > 1 {
+			logger.Debugf("not checking the size of the %d osds on pvc %q", pvcOSDs[pvcName], pvcName)
+			continue
+		}
+		if err := m.resizeOSDIfGrown(&deployments.Items[i]); err != nil {
+			logger.Errorf("failed to resize the osd of deployment %q. %v", deployments.Items[i].Name, err)
+		}
+	}
+	return nil
+}
+
+func (m *OSDHealthMonitor) resizeOSDIfGrown(d *apps.Deployment) error {
+	osdID, err := strconv.Atoi(d.Labels[OsdIdLabelKey])
+	if err != nil {
+		return errors.Wrapf(err, "failed to parse the osd id of deployment %q", d.Name)
+	}
+	pvcs := m.context.Clientset.CoreV1().PersistentVolumeClaims(m.clusterInfo.Namespace)
+	pvc, err := pvcs.Get(d.Labels[OSDOverPVCLabelKey], metav1.GetOptions{})
+	if err != nil {
+		return errors.Wrapf(err, "failed to get the pvc of osd.%d", osdID)
+	}
+
+	capacity, grown := pvcGrown(pvc)
+	if capacity == nil {
+		return nil
+	}
+	osdSize, recorded := pvc.Annotations[osdSizeAnnotation]
+	annotations := map[string]string{}
+	switch {
+	case !recorded:
+		// the size was never recorded, it is the size of the pvc
+		annotations[osdSizeAnnotation] = capacity.String()
+		annotations[osdWeightSizeAnnotation] = capacity.String()
+
+	case grown:
+		// the size is recorded as soon as the osd restarts so the osd is only restarted once
+		logger.Infof("pvc %q of osd.%d grew to %s, restarting the osd to expand it", pvc.Name, osdID, capacity.String())
+		if err := m.restartOSD(d.Name, osdID); err != nil {
+			return err
+		}
+		annotations[osdSizeAnnotation] = capacity.String()
+
+	case pvc.Annotations[osdWeightSizeAnnotation] != osdSize:
+		// the osd must run on the expanded device before its weight is updated
+		running, err := m.osdRunning(osdID)
+		if err != nil {
+			return err
+		}
+		if !running {
+			logger.Infof("waiting for osd.%d to run on its expanded device before updating its crush weight", osdID)
+			return nil
+		}
+		size, err := resource.ParseQuantity(osdSize)
+		if err != nil {
+			return errors.Wrapf(err, "failed to parse the size %q of osd.%d", osdSize, osdID)
+		}
+		if err := client.CrushReweight(m.context, m.clusterInfo, osdID, float64(size.Value())/crushWeightUnit); err != nil {
+			return err
+		}
+		logger.Infof("expanded osd.%d to %s", osdID, osdSize)
+		annotations[osdWeightSizeAnnotation] = osdSize
+	}
+
+	if len(annotations) == 0 {
+		return nil
+	}
+	if pvc.Annotations == nil {
+		pvc.Annotations = map[string]string{}
+	}
+	for key, value := range annotations {
+		pvc.Annotations[key] = value
+	}
+	if _, err := pvcs.Update(pvc); err != nil {
+		return errors.Wrapf(err, "failed to record the size of osd.%d on pvc %q", osdID, pvc.Name)
+	}
+	return nil
+}
+
+// pvcGrown returns the capacity of the pvc and whether it is bigger than the size of the osd
+func pvcGrown(pvc *v1.PersistentVolumeClaim) (*resource.Quantity, bool) {
+	capacity, ok := pvc.Status.Capacity[v1.ResourceStorage]
+	if !ok {
+		return nil, false
+	}
+	recorded, ok := pvc.Annotations[osdSizeAnnotation]
+	if !ok {
+		return &capacity, false
+	}
+	size, err := resource.ParseQuantity(recorded)
+	if err != nil {
+		logger.Warningf("failed to parse the size %q recorded on pvc %q. %v", recorded, pvc.Name, err)
+		return &capacity, false
+	}
+	return &capacity, capacity.Cmp(size) > 0
+}
+
+// restartOSD restarts the osd so its init container expands the bluestore device
+func (m *OSDHealthMonitor) restartOSD(deploymentName string, osdID int) error {
+	if err := client.OkToStop(m.context, m.clusterInfo, deploymentName, "osd", strconv.Itoa(osdID)); err != nil {
+		return errors.Wrapf(err, "osd.%d cannot be restarted to expand it", osdID)
+	}
+
+	pods := m.context.Clientset.CoreV1().Pods(m.clusterInfo.Namespace)
+	oldPods, err := pods.List(metav1.ListOptions{LabelSelector: fmt.Sprintf("%s=%d", OsdIdLabelKey, osdID)})
+	if err != nil {
+		return errors.Wrapf(err, "failed to list the pods of osd.%d", osdID)
+	}
+	for _, pod := range oldPods.Items {
+		if err := pods.Delete(pod.Name, &metav1.DeleteOptions{}); err != nil {
+			return errors.Wrapf(err, "failed to restart pod %q of osd.%d", pod.Name, osdID)
+		}
+	}
+	return nil
+}
+
+// osdRunning returns whether a pod of the osd is ready and not being deleted
+func (m *OSDHealthMonitor) osdRunning(osdID int) (bool, error) {
+	opts := metav1.ListOptions{LabelSelector: fmt.Sprintf("%s=%d", OsdIdLabelKey, osdID)}
+	pods, err := m.context.Clientset.CoreV1().Pods(m.clusterInfo.Namespace).List(opts)
+	if err != nil {
+		return false, errors.Wrapf(err, "failed to list the pods of osd.%d", osdID)
+	}
+	for i := range pods.Items {
+		if pods.Items[i].DeletionTimestamp == nil && isPodReady(&pods.Items[i]) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func isPodReady(pod *v1.Pod) bool {
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == v1.PodReady {
+			return condition.Status == v1.ConditionTrue
+		}
+	}
+	return false
+}
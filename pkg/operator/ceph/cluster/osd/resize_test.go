@@ -0,0 +1,176 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package osd
+
+import (
+	"testing"
+
+	"github.com/pkg/errors"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/clusterd"
+	"github.com/rook/rook/pkg/daemon/ceph/client"
+	"github.com/rook/rook/pkg/operator/k8sutil"
+	exectest "github.com/rook/rook/pkg/util/exec/test"
+	"github.com/stretchr/testify/assert"
+	apps "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func newResizeTestPVC(capacity, recorded string) *v1.PersistentVolumeClaim {
+	pvc := &v1.PersistentVolumeClaim{ObjectMeta: metav1.ObjectMeta{Name: "set1-data-0", Namespace: "ns"}}
+	if capacity != "" {
+		pvc.Status.Capacity = v1.ResourceList{v1.ResourceStorage: resource.MustParse(capacity)}
+	}
+	if recorded != "" {
+		pvc.Annotations = map[string]string{osdSizeAnnotation: recorded}
+	}
+	return pvc
+}
+
+func TestPVCGrown(t *testing.T) {
+	capacity, grown := pvcGrown(newResizeTestPVC("", ""))
+	assert.Nil(t, capacity)
+	assert.False(t, grown)
+
+	// the size was never recorded
+	capacity, grown = pvcGrown(newResizeTestPVC("10Gi", ""))
+	assert.Equal(t, "10Gi", capacity.String())
+	assert.False(t, grown)
+
+	capacity, grown = pvcGrown(newResizeTestPVC("10Gi", "10Gi"))
+	assert.Equal(t, "10Gi", capacity.String())
+	assert.False(t, grown)
+
+	capacity, grown = pvcGrown(newResizeTestPVC("20Gi", "10Gi"))
+	assert.Equal(t, "20Gi", capacity.String())
+	assert.True(t, grown)
+
+	_, grown = pvcGrown(newResizeTestPVC("20Gi", "foo"))
+	assert.False(t, grown)
+}
+
+func TestCheckOSDResizeRecordsSize(t *testing.T) {
+	d := &apps.Deployment{ObjectMeta: metav1.ObjectMeta{
+		Name:      "rook-ceph-osd-0",
+		Namespace: "ns",
+		Labels: map[string]string{
+			k8sutil.AppAttr:    AppName,
+			OsdIdLabelKey:      "0",
+			OSDOverPVCLabelKey: "set1-data-0",
+		},
+	}}
+	clientset := fake.NewSimpleClientset(d, newResizeTestPVC("10Gi", ""))
+	context := &clusterd.Context{Clientset: clientset}
+	m := NewOSDHealthMonitor(context, &client.ClusterInfo{Namespace: "ns"}, false, cephv1.CephClusterHealthCheckSpec{})
+
+	assert.NoError(t, m.checkOSDResize())
+	pvc, err := clientset.CoreV1().PersistentVolumeClaims("ns").Get("set1-data-0", metav1.GetOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, "10Gi", pvc.Annotations[osdSizeAnnotation])
+	assert.Equal(t, "10Gi", pvc.Annotations[osdWeightSizeAnnotation])
+}
+
+func TestCheckOSDResizeRetriesReweight(t *testing.T) {
+	d := &apps.Deployment{ObjectMeta: metav1.ObjectMeta{
+		Name:      "rook-ceph-osd-0",
+		Namespace: "ns",
+		Labels: map[string]string{
+			k8sutil.AppAttr:    AppName,
+			OsdIdLabelKey:      "0",
+			OSDOverPVCLabelKey: "set1-data-0",
+		},
+	}}
+	pvc := newResizeTestPVC("20Gi", "20Gi")
+	pvc.Annotations[osdWeightSizeAnnotation] = "10Gi"
+	pod := &v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "rook-ceph-osd-0-abc", Namespace: "ns", Labels: map[string]string{OsdIdLabelKey: "0"}}}
+	clientset := fake.NewSimpleClientset(d, pvc, pod)
+	reweights := 0
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutputFile: func(command string, outFileArg string, args ...string) (string, error) {
+			if args[0] == "osd" && args[1] == "crush" && args[2] == "reweight" {
+				assert.Equal(t, "osd.0", args[3])
+				reweights++
+				if reweights == 1 {
+					return "", errors.New("failed to reweight")
+				}
+				return "", nil
+			}
+			return "", errors.Errorf("unexpected command %v", args)
+		},
+	}
+	context := &clusterd.Context{Clientset: clientset, Executor: executor}
+	m := NewOSDHealthMonitor(context, &client.ClusterInfo{Namespace: "ns"}, false, cephv1.CephClusterHealthCheckSpec{})
+	weightSize := func() string {
+		pvc, err := clientset.CoreV1().PersistentVolumeClaims("ns").Get("set1-data-0", metav1.GetOptions{})
+		assert.NoError(t, err)
+		return pvc.Annotations[osdWeightSizeAnnotation]
+	}
+
+	// the osd is not reweighted before it runs again
+	assert.NoError(t, m.checkOSDResize())
+	assert.Equal(t, 0, reweights)
+	assert.Equal(t, "10Gi", weightSize())
+
+	pod.Status.Conditions = []v1.PodCondition{{Type: v1.PodReady, Status: v1.ConditionTrue}}
+	_, err := clientset.CoreV1().Pods("ns").Update(pod)
+	assert.NoError(t, err)
+
+	// the failed reweight is retried by the next check without restarting the osd
+	assert.NoError(t, m.checkOSDResize())
+	assert.Equal(t, 1, reweights)
+	assert.Equal(t, "10Gi", weightSize())
+	assert.NoError(t, m.checkOSDResize())
+	assert.Equal(t, 2, reweights)
+	assert.Equal(t, "20Gi", weightSize())
+
+	// nothing is done once the weight is up to date
+	assert.NoError(t, m.checkOSDResize())
+	assert.Equal(t, 2, reweights)
+}
+
+func TestCheckOSDResizeSkipsMultiOSDPVC(t *testing.T) {
+	newDeployment := func(id string) *apps.Deployment {
+		return &apps.Deployment{ObjectMeta: metav1.ObjectMeta{
+			Name:      "rook-ceph-osd-" + id,
+			Namespace: "ns",
+			Labels: map[string]string{
+				k8sutil.AppAttr:    AppName,
+				OsdIdLabelKey:      id,
+				OSDOverPVCLabelKey: "set1-data-0",
+			},
+		}}
+	}
+	clientset := fake.NewSimpleClientset(newDeployment("0"), newDeployment("1"), newResizeTestPVC("10Gi", ""))
+	context := &clusterd.Context{Clientset: clientset}
+	m := NewOSDHealthMonitor(context, &client.ClusterInfo{Namespace: "ns"}, false, cephv1.CephClusterHealthCheckSpec{})
+
+	// the osds carved out of the pvc are not expanded
+	assert.NoError(t, m.checkOSDResize())
+	pvc, err := clientset.CoreV1().PersistentVolumeClaims("ns").Get("set1-data-0", metav1.GetOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, "", pvc.Annotations[osdSizeAnnotation])
+}
+
+func TestIsPodReady(t *testing.T) {
+	pod := &v1.Pod{}
+	assert.False(t, isPodReady(pod))
+	pod.Status.Conditions = []v1.PodCondition{{Type: v1.PodReady, Status: v1.ConditionTrue}}
+	assert.True(t, isPodReady(pod))
+}
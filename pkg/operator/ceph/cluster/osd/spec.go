@@ -295,6 +295,7 @@ func (c *Cluster) makeDeployment(osdProps osdProperties, osd OSDInfo, provisionC
 
 	args = append(args, opconfig.LoggingFlags()...)
 	args = append(args, osdOnSDNFlag(c.spec.Network)...)
+	args = append(args, c.osdMemoryTargetFlag(osdProps)...)
 
 	osdDataDirPath := activateOSDMountPath + osdID
 	if osdProps.onPVC() && osd.CVMode == "lvm" {
@@ -479,18 +480,35 @@ func (c *Cluster) makeDeployment(osdProps osdProperties, osd OSDInfo, provisionC
 	k8sutil.AddRookVersionLabelToDeployment(deployment)
 	cephv1.GetOSDAnnotations(c.spec.Annotations).ApplyToObjectMeta(&deployment.ObjectMeta)
 	cephv1.GetOSDAnnotations(c.spec.Annotations).ApplyToObjectMeta(&deployment.Spec.Template.ObjectMeta)
+	setDeviceIdentityAnnotations(&deployment.ObjectMeta, osd)
 	controller.AddCephVersionLabelToDeployment(c.clusterInfo.CephVersion, deployment)
 	controller.AddCephVersionLabelToDeployment(c.clusterInfo.CephVersion, deployment)
 	k8sutil.SetOwnerRef(&deployment.ObjectMeta, &c.clusterInfo.OwnerRef)
 	if !osdProps.onPVC() {
-		cephv1.GetOSDPlacement(c.spec.Placement).ApplyToPodSpec(&deployment.Spec.Template.Spec)
+		c.nodeOSDPlacement(osdProps.crushDeviceClass).ApplyToPodSpec(&deployment.Spec.Template.Spec)
 	} else {
 		osdProps.placement.ApplyToPodSpec(&deployment.Spec.Template.Spec)
+		// the osds carved out of a portable pvc must run on the node the pvc is attached to
+		if osdProps.portable && osdProps.storeConfig.OSDsPerDevice > 1 {
+			addPVCPodAffinity(&deployment.Spec.Template.Spec, osdProps.pvc.ClaimName)
+		}
 	}
 
 	return deployment, nil
 }
 
+// addPVCPodAffinity requires the osd pod to run on the same node as the other osds of the pvc
+func addPVCPodAffinity(spec *v1.PodSpec, pvcName string) {
+	if spec.Affinity.PodAffinity == nil {
+		spec.Affinity.PodAffinity = &v1.PodAffinity{}
+	}
+	spec.Affinity.PodAffinity.RequiredDuringSchedulingIgnoredDuringExecution = append(spec.Affinity.PodAffinity.RequiredDuringSchedulingIgnoredDuringExecution,
+		v1.PodAffinityTerm{
+			LabelSelector: &metav1.LabelSelector{MatchLabels: map[string]string{OSDOverPVCLabelKey: pvcName}},
+			TopologyKey:   v1.LabelHostname,
+		})
+}
+
 // To get rook inside the container, the config init container needs to copy "tini" and "rook" binaries into a volume.
 // Get the config flag so rook will copy the binaries and create the volume and mount that will be shared between
 // the init container and the daemon container
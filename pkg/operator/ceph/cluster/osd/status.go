@@ -20,11 +20,13 @@ package osd
 import (
 	"encoding/json"
 	"fmt"
+	"sync"
 	"time"
 
 	"github.com/rook/rook/pkg/operator/ceph/config"
 	"github.com/rook/rook/pkg/operator/k8sutil"
 	"github.com/rook/rook/pkg/util"
+	apps "k8s.io/api/apps/v1"
 	v1 "k8s.io/api/core/v1"
 	kerrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -46,8 +48,18 @@ const (
 )
 
 type provisionConfig struct {
+	// errorMessages and deploymentUpdates are guarded by the mutex since the nodes and PVCs are
+	// provisioned in parallel
+	mutex         sync.Mutex
 	errorMessages []string
-	DataPathMap   *config.DataPathMap // location to store data in container
+	// the existing osd deployments to update once the nodes and PVCs are provisioned
+	deploymentUpdates []osdDeploymentUpdate
+	DataPathMap       *config.DataPathMap // location to store data in container
+}
+
+type osdDeploymentUpdate struct {
+	osdID      int
+	deployment *apps.Deployment
 }
 
 func (c *Cluster) newProvisionConfig() *provisionConfig {
@@ -58,9 +70,28 @@ func (c *Cluster) newProvisionConfig() *provisionConfig {
 
 func (c *provisionConfig) addError(message string, args ...interface{}) {
 	logger.Errorf(message, args...)
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
 	c.errorMessages = append(c.errorMessages, fmt.Sprintf(message, args...))
 }
 
+// addDeploymentUpdate queues the update of an existing osd deployment. The updates restart the osds,
+// they are not run by the parallel provisioning workers so the osds are restarted one at a time.
+func (c *provisionConfig) addDeploymentUpdate(osdID int, deployment *apps.Deployment) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.deploymentUpdates = append(c.deploymentUpdates, osdDeploymentUpdate{osdID: osdID, deployment: deployment})
+}
+
+// takeDeploymentUpdates returns the queued updates of the osd deployments and clears the queue
+func (c *provisionConfig) takeDeploymentUpdates() []osdDeploymentUpdate {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	updates := c.deploymentUpdates
+	c.deploymentUpdates = nil
+	return updates
+}
+
 func (c *Cluster) updateOSDStatus(node string, status OrchestrationStatus) {
 	UpdateNodeStatus(c.kv, node, status)
 }
@@ -116,10 +147,12 @@ func parseOrchestrationStatus(data map[string]string) *OrchestrationStatus {
 }
 
 func (c *Cluster) completeProvision(config *provisionConfig) bool {
-	return c.completeOSDsForAllNodes(config, true, completeProvisionTimeout)
+	completed := c.completeOSDsForAllNodes(config, true, completeProvisionTimeout)
+	c.updateOSDDeployments(config)
+	return completed
 }
 
-func (c *Cluster) checkNodesCompleted(selector string, config *provisionConfig, configOSDs bool) (int, *util.Set, bool, *v1.ConfigMapList, error) {
+func (c *Cluster) checkNodesCompleted(selector string, config *provisionConfig, configOSDs bool, pool *workerPool) (int, *util.Set, bool, *v1.ConfigMapList, error) {
 	opts := metav1.ListOptions{
 		LabelSelector: selector,
 		Watch:         false,
@@ -144,7 +177,7 @@ func (c *Cluster) checkNodesCompleted(selector string, config *provisionConfig,
 			continue
 		}
 		localconfigMap := configMap
-		completed := c.handleStatusConfigMapStatus(node, config, &localconfigMap, configOSDs)
+		completed := c.handleStatusConfigMapStatus(node, config, &localconfigMap, configOSDs, pool)
 		if !completed {
 			remainingNodes.Add(node)
 		}
@@ -161,7 +194,12 @@ func (c *Cluster) completeOSDsForAllNodes(config *provisionConfig, configOSDs bo
 		orchestrationStatusKey, provisioningLabelKey,
 	)
 
-	originalNodes, remainingNodes, completed, statuses, err := c.checkNodesCompleted(selector, config, configOSDs)
+	// the osd daemons of the completed nodes are started in the background while watching for
+	// the other nodes, they must all be started before returning
+	pool := c.newProvisioningPool()
+	defer pool.wait()
+
+	originalNodes, remainingNodes, completed, statuses, err := c.checkNodesCompleted(selector, config, configOSDs, pool)
 	if err == nil && completed {
 		return true
 	}
@@ -192,7 +230,7 @@ func (c *Cluster) completeOSDsForAllNodes(config *provisionConfig, configOSDs bo
 					logger.Infof("orchestration status config map result channel closed, will restart watch.")
 					w.Stop()
 					<-time.After(5 * time.Second)
-					leftNodes, leftRemainingNodes, completed, _, err := c.checkNodesCompleted(selector, config, configOSDs)
+					leftNodes, leftRemainingNodes, completed, _, err := c.checkNodesCompleted(selector, config, configOSDs, pool)
 					if err == nil {
 						if completed {
 							logger.Infof("additional %d/%d node(s) completed osd provisioning", leftNodes, originalNodes)
@@ -219,7 +257,7 @@ func (c *Cluster) completeOSDsForAllNodes(config *provisionConfig, configOSDs bo
 						logger.Infof("skipping event from node %s status update since it is already completed", node)
 						continue
 					}
-					completed := c.handleStatusConfigMapStatus(node, config, configMap, configOSDs)
+					completed := c.handleStatusConfigMapStatus(node, config, configMap, configOSDs, pool)
 					if completed {
 						remainingNodes.Remove(node)
 						if remainingNodes.Count() == 0 {
@@ -249,7 +287,7 @@ func (c *Cluster) completeOSDsForAllNodes(config *provisionConfig, configOSDs bo
 	}
 }
 
-func (c *Cluster) handleStatusConfigMapStatus(nodeName string, config *provisionConfig, configMap *v1.ConfigMap, configOSDs bool) bool {
+func (c *Cluster) handleStatusConfigMapStatus(nodeName string, config *provisionConfig, configMap *v1.ConfigMap, configOSDs bool, pool *workerPool) bool {
 
 	status := parseOrchestrationStatus(configMap.Data)
 	if status == nil {
@@ -259,15 +297,17 @@ func (c *Cluster) handleStatusConfigMapStatus(nodeName string, config *provision
 	logger.Infof("osd orchestration status for node %s is %s", nodeName, status.Status)
 	if status.Status == OrchestrationStatusCompleted {
 		if configOSDs {
-			if status.PvcBackedOSD {
-				c.startOSDDaemonsOnPVC(nodeName, config, configMap, status)
-			} else {
-				c.startOSDDaemonsOnNode(nodeName, config, configMap, status)
-			}
-			// remove the status configmap that indicated the progress
-			if err := c.kv.ClearStore(fmt.Sprintf(orchestrationStatusMapName, nodeName)); err != nil {
-				logger.Errorf("failed to remove the status configmap. %v", err)
-			}
+			pool.run(func() {
+				if status.PvcBackedOSD {
+					c.startOSDDaemonsOnPVC(nodeName, config, configMap, status)
+				} else {
+					c.startOSDDaemonsOnNode(nodeName, config, configMap, status)
+				}
+				// remove the status configmap that indicated the progress
+				if err := c.kv.ClearStore(fmt.Sprintf(orchestrationStatusMapName, nodeName)); err != nil {
+					logger.Errorf("failed to remove the status configmap. %v", err)
+				}
+			})
 		}
 
 		return true
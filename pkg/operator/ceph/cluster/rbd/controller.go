@@ -78,7 +78,7 @@ type ReconcileCephRBDMirror struct {
 // Add creates a new cephRBDMirror Controller and adds it to the Manager. The Manager will set fields on the Controller
 // and Start it when the Manager is Started.
 func Add(mgr manager.Manager, context *clusterd.Context) error {
-	return add(mgr, newReconciler(mgr, context))
+	return add(mgr, newReconciler(mgr, context), context)
 }
 
 // newReconciler returns a new reconcile.Reconciler
@@ -95,9 +95,9 @@ func newReconciler(mgr manager.Manager, context *clusterd.Context) reconcile.Rec
 	}
 }
 
-func add(mgr manager.Manager, r reconcile.Reconciler) error {
+func add(mgr manager.Manager, r reconcile.Reconciler, context *clusterd.Context) error {
 	// Create a new controller
-	c, err := controller.New(controllerName, mgr, controller.Options{Reconciler: r})
+	c, err := controller.New(controllerName, mgr, opcontroller.SerialControllerOptions(context, controllerName, r))
 	if err != nil {
 		return err
 	}
@@ -149,6 +149,13 @@ func (r *ReconcileCephRBDMirror) reconcile(request reconcile.Request) (reconcile
 		return reconcile.Result{}, errors.Wrap(err, "failed to get cephRBDMirror")
 	}
 
+	// Do nothing while the reconcile is paused, not even the deletion
+	if opcontroller.IsReconcilePaused(cephRBDMirror) {
+		logger.Infof("reconcile of CephRBDMirror %q is paused with the %q annotation, skipping", request.NamespacedName, opcontroller.PauseReconcileAnnotation)
+		updateStatus(r.client, request.NamespacedName, k8sutil.PausedStatus)
+		return reconcile.Result{}, nil
+	}
+
 	// The CR was just created, initializing status fields
 	if cephRBDMirror.Status == nil {
 		updateStatus(r.client, request.NamespacedName, k8sutil.Created)
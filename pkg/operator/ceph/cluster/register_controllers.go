@@ -20,6 +20,8 @@ import (
 	"github.com/pkg/errors"
 	"github.com/rook/rook/pkg/clusterd"
 	"github.com/rook/rook/pkg/operator/ceph/cluster/crash"
+	"github.com/rook/rook/pkg/operator/ceph/cluster/mgr/dashboarduser"
+	"github.com/rook/rook/pkg/operator/ceph/cluster/osd/removal"
 	"github.com/rook/rook/pkg/operator/ceph/cluster/rbd"
 	"github.com/rook/rook/pkg/operator/ceph/disruption/clusterdisruption"
 	"github.com/rook/rook/pkg/operator/ceph/disruption/controllerconfig"
@@ -67,6 +69,8 @@ var AddToManagerFuncs = []func(manager.Manager, *clusterd.Context) error{
 	file.Add,
 	nfs.Add,
 	rbd.Add,
+	removal.Add,
+	dashboarduser.Add,
 }
 
 // AddToManager adds all the registered controllers to the passed manager.
@@ -33,6 +33,13 @@ import (
 var (
 	conditions   *[]cephv1.Condition
 	conditionMap = make(map[cephv1.ConditionType]v1.ConditionStatus)
+
+	// informationalConditions only report a state next to the phase of the cluster, they don't
+	// change its phase and message when they become true
+	informationalConditions = map[cephv1.ConditionType]bool{
+		cephv1.ConditionDryRun:        true,
+		cephv1.ConditionMonQuorumRisk: true,
+	}
 )
 
 // ConditionExport function will export each condition into the cluster custom resource
@@ -79,7 +86,7 @@ func setCondition(c *clusterd.Context, namespaceName types.NamespacedName, newCo
 	}
 	cluster.Status.Conditions = *conditions
 
-	if newCondition.Status == v1.ConditionTrue {
+	if newCondition.Status == v1.ConditionTrue && !informationalConditions[newCondition.Type] {
 		cluster.Status.Phase = newCondition.Type
 		if state := translatePhasetoState(newCondition.Type); state != "" {
 			cluster.Status.State = state
@@ -46,6 +46,11 @@ var (
 
 	// OperatorCephBaseImageVersion is the ceph version in the operator image
 	OperatorCephBaseImageVersion string
+
+	// DryRun Whether the operator only reports the changes it would apply. If true, the changes to
+	// Kubernetes resources are sent to the API server in dry-run mode and the ceph commands which
+	// would change the cluster are not executed.
+	DryRun = false
 )
 
 // IsReadyToReconcile determines if a controller is ready to reconcile or not
@@ -0,0 +1,172 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rook/rook/pkg/clusterd"
+	"github.com/rook/rook/pkg/operator/k8sutil"
+	"golang.org/x/time/rate"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/util/workqueue"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// The operator settings tuning the controllers. Each setting can be overridden for a single
+// controller by appending the name of the controller, e.g. ROOK_MAX_CONCURRENT_RECONCILES_CEPH_BLOCK_POOL
+// for the "ceph-block-pool-controller".
+const (
+	// the number of requests a controller reconciles in parallel
+	maxConcurrentReconcilesSetting = "ROOK_MAX_CONCURRENT_RECONCILES"
+	// the delay before the first retry of a failed request, doubled on every failure
+	rateLimitBaseDelaySetting = "ROOK_RECONCILE_RATE_LIMIT_BASE_DELAY"
+	// the maximum delay between the retries of a failed request
+	rateLimitMaxDelaySetting = "ROOK_RECONCILE_RATE_LIMIT_MAX_DELAY"
+	// the overall number of requests per second and the burst a controller can queue
+	rateLimitQPSSetting   = "ROOK_RECONCILE_RATE_LIMIT_QPS"
+	rateLimitBurstSetting = "ROOK_RECONCILE_RATE_LIMIT_BURST"
+)
+
+// The defaults are the ones of the controller-runtime
+const (
+	defaultMaxConcurrentReconciles = 1
+	defaultRateLimitBaseDelay      = 5 * time.Millisecond
+	defaultRateLimitMaxDelay       = 1000 * time.Second
+	defaultRateLimitQPS            = 10
+	defaultRateLimitBurst          = 100
+)
+
+// ControllerOptions returns the options of the controller with the given name. The number of
+// concurrent reconciles and the rate limits of the workqueue are read from the operator settings
+// so that large deployments can tune the reconcile throughput. The settings are only read when the
+// controller is created, the operator must be restarted to apply a change.
+func ControllerOptions(context *clusterd.Context, controllerName string, r reconcile.Reconciler) controller.Options {
+	settings := operatorSettings(context)
+	s := &controllerSettings{settings: settings, suffix: controllerSettingSuffix(controllerName)}
+
+	maxConcurrentReconciles := s.getInt(maxConcurrentReconcilesSetting, defaultMaxConcurrentReconciles)
+	baseDelay := s.getDuration(rateLimitBaseDelaySetting, defaultRateLimitBaseDelay)
+	maxDelay := s.getDuration(rateLimitMaxDelaySetting, defaultRateLimitMaxDelay)
+	qps := s.getFloat(rateLimitQPSSetting, defaultRateLimitQPS)
+	burst := s.getInt(rateLimitBurstSetting, defaultRateLimitBurst)
+	logger.Infof("%q: max concurrent reconciles %d, rate limiter base delay %s, max delay %s, qps %v, burst %d",
+		controllerName, maxConcurrentReconciles, baseDelay, maxDelay, qps, burst)
+
+	return controller.Options{
+		Reconciler:              r,
+		MaxConcurrentReconciles: maxConcurrentReconciles,
+		RateLimiter: workqueue.NewMaxOfRateLimiter(
+			workqueue.NewItemExponentialFailureRateLimiter(baseDelay, maxDelay),
+			&workqueue.BucketRateLimiter{Limiter: rate.NewLimiter(rate.Limit(qps), burst)},
+		),
+	}
+}
+
+// SerialControllerOptions returns the options of a controller that must reconcile one request at a
+// time because its reconciler keeps the state of the request, like the cluster info, on its struct.
+// Only the rate limits of the workqueue are read from the operator settings.
+func SerialControllerOptions(context *clusterd.Context, controllerName string, r reconcile.Reconciler) controller.Options {
+	options := ControllerOptions(context, controllerName, r)
+	if options.MaxConcurrentReconciles > 1 {
+		logger.Warningf("ignoring the max concurrent reconciles %d of %q, its requests are reconciled one at a time", options.MaxConcurrentReconciles, controllerName)
+		options.MaxConcurrentReconciles = 1
+	}
+	return options
+}
+
+// operatorSettings returns the settings of the operator ConfigMap
+func operatorSettings(context *clusterd.Context) map[string]string {
+	namespace := os.Getenv(k8sutil.PodNamespaceEnvVar)
+	cm, err := context.Clientset.CoreV1().ConfigMaps(namespace).Get(OperatorSettingConfigMapName, metav1.GetOptions{})
+	if err != nil {
+		if !kerrors.IsNotFound(err) {
+			logger.Warningf("failed to read the operator settings, using the default controller settings. %v", err)
+		}
+		return map[string]string{}
+	}
+	return cm.Data
+}
+
+// controllerSettingSuffix returns the suffix of the settings of a controller, e.g. "_CEPH_BLOCK_POOL"
+func controllerSettingSuffix(controllerName string) string {
+	name := strings.TrimSuffix(controllerName, "-controller")
+	return "_" + strings.ToUpper(strings.Replace(name, "-", "_", -1))
+}
+
+type controllerSettings struct {
+	settings map[string]string
+	suffix   string
+}
+
+// get returns the value of the setting for the controller, looking in order for the controller
+// setting and the global setting in the operator ConfigMap and then in the env
+func (s *controllerSettings) get(name string) (string, bool) {
+	for _, key := range []string{name + s.suffix, name} {
+		if value, ok := s.settings[key]; ok {
+			return value, true
+		}
+		if value, ok := os.LookupEnv(key); ok {
+			return value, true
+		}
+	}
+	return "", false
+}
+
+func (s *controllerSettings) getInt(name string, defaultValue int) int {
+	value, ok := s.get(name)
+	if !ok {
+		return defaultValue
+	}
+	i, err := strconv.Atoi(value)
+	if err != nil || i <= 0 {
+		logger.Warningf("invalid value %q for setting %q, using the default %d", value, name, defaultValue)
+		return defaultValue
+	}
+	return i
+}
+
+func (s *controllerSettings) getFloat(name string, defaultValue float64) float64 {
+	value, ok := s.get(name)
+	if !ok {
+		return defaultValue
+	}
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil || f <= 0 {
+		logger.Warningf("invalid value %q for setting %q, using the default %v", value, name, defaultValue)
+		return defaultValue
+	}
+	return f
+}
+
+func (s *controllerSettings) getDuration(name string, defaultValue time.Duration) time.Duration {
+	value, ok := s.get(name)
+	if !ok {
+		return defaultValue
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d <= 0 {
+		logger.Warningf("invalid value %q for setting %q, using the default %s", value, name, defaultValue)
+		return defaultValue
+	}
+	return d
+}
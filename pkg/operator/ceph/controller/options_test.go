@@ -0,0 +1,73 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/rook/rook/pkg/clusterd"
+	"github.com/rook/rook/pkg/operator/k8sutil"
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestControllerSettingSuffix(t *testing.T) {
+	assert.Equal(t, "_CEPH_BLOCK_POOL", controllerSettingSuffix("ceph-block-pool-controller"))
+	assert.Equal(t, "_NODEDRAIN", controllerSettingSuffix("nodedrain-controller"))
+}
+
+func TestControllerOptions(t *testing.T) {
+	os.Setenv(k8sutil.PodNamespaceEnvVar, "rook-ceph")
+	defer os.Unsetenv(k8sutil.PodNamespaceEnvVar)
+	clientset := fake.NewSimpleClientset()
+	context := &clusterd.Context{Clientset: clientset}
+
+	// defaults without the operator ConfigMap
+	opts := ControllerOptions(context, "ceph-block-pool-controller", nil)
+	assert.Equal(t, 1, opts.MaxConcurrentReconciles)
+	assert.NotNil(t, opts.RateLimiter)
+
+	cm := &v1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: OperatorSettingConfigMapName, Namespace: "rook-ceph"},
+		Data: map[string]string{
+			"ROOK_MAX_CONCURRENT_RECONCILES":                 "2",
+			"ROOK_MAX_CONCURRENT_RECONCILES_CEPH_BLOCK_POOL": "8",
+			"ROOK_RECONCILE_RATE_LIMIT_BASE_DELAY":           "1s",
+			"ROOK_RECONCILE_RATE_LIMIT_QPS":                  "invalid",
+		},
+	}
+	_, err := clientset.CoreV1().ConfigMaps("rook-ceph").Create(cm)
+	assert.NoError(t, err)
+
+	// the controller setting overrides the global one
+	opts = ControllerOptions(context, "ceph-block-pool-controller", nil)
+	assert.Equal(t, 8, opts.MaxConcurrentReconciles)
+	opts = ControllerOptions(context, "ceph-file-controller", nil)
+	assert.Equal(t, 2, opts.MaxConcurrentReconciles)
+
+	// the first retry of a failed request waits for the base delay
+	assert.Equal(t, time.Second, opts.RateLimiter.When("item"))
+
+	// a serial controller only reads the rate limits
+	opts = SerialControllerOptions(context, "ceph-file-controller", nil)
+	assert.Equal(t, 1, opts.MaxConcurrentReconciles)
+	assert.Equal(t, time.Second, opts.RateLimiter.When("item"))
+}
@@ -0,0 +1,62 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// PauseReconcileAnnotation is the annotation which pauses the reconcile of a CR when set to
+	// "true". Admins can set it to perform manual maintenance on Ceph without the operator
+	// undoing their changes. The reconcile resumes as soon as the annotation is removed.
+	PauseReconcileAnnotation = "rook.io/pause-reconcile"
+)
+
+// IsReconcilePaused returns whether the reconcile of the object is paused
+func IsReconcilePaused(obj metav1.Object) bool {
+	return obj.GetAnnotations()[PauseReconcileAnnotation] == "true"
+}
+
+// IsClusterReconcilePaused returns whether the reconcile of the CephCluster in the namespace is
+// paused. The health checkers of the cluster daemons run outside of the reconcile and must check it
+// too so they don't undo a manual maintenance.
+func IsClusterReconcilePaused(c client.Client, namespace string) (bool, error) {
+	clusterList := &cephv1.CephClusterList{}
+	if err := c.List(context.TODO(), clusterList, client.InNamespace(namespace)); err != nil {
+		return false, errors.Wrapf(err, "failed to list the ceph clusters in namespace %q", namespace)
+	}
+	for i := range clusterList.Items {
+		if IsReconcilePaused(&clusterList.Items[i]) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// isReconcilePausedChanged returns whether the reconcile of the object was paused or resumed
+func isReconcilePausedChanged(objOld, objNew metav1.Object) bool {
+	if objOld == nil || objNew == nil {
+		return false
+	}
+	return IsReconcilePaused(objOld) != IsReconcilePaused(objNew)
+}
@@ -0,0 +1,82 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"testing"
+
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/client/clientset/versioned/scheme"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+)
+
+func TestIsReconcilePaused(t *testing.T) {
+	pool := &cephv1.CephBlockPool{}
+	assert.False(t, IsReconcilePaused(pool))
+
+	pool.Annotations = map[string]string{PauseReconcileAnnotation: "false"}
+	assert.False(t, IsReconcilePaused(pool))
+
+	pool.Annotations[PauseReconcileAnnotation] = "true"
+	assert.True(t, IsReconcilePaused(pool))
+}
+
+func TestIsClusterReconcilePaused(t *testing.T) {
+	cluster := &cephv1.CephCluster{ObjectMeta: metav1.ObjectMeta{Name: "rook-ceph", Namespace: "ns"}}
+	cl := fake.NewFakeClientWithScheme(scheme.Scheme, cluster)
+
+	paused, err := IsClusterReconcilePaused(cl, "ns")
+	assert.NoError(t, err)
+	assert.False(t, paused)
+
+	cluster.Annotations = map[string]string{PauseReconcileAnnotation: "true"}
+	assert.NoError(t, cl.Update(context.TODO(), cluster))
+	paused, err = IsClusterReconcilePaused(cl, "ns")
+	assert.NoError(t, err)
+	assert.True(t, paused)
+
+	// the clusters of other namespaces are ignored
+	paused, err = IsClusterReconcilePaused(cl, "other")
+	assert.NoError(t, err)
+	assert.False(t, paused)
+}
+
+func TestPauseAnnotationTriggersReconcile(t *testing.T) {
+	oldPool := &cephv1.CephBlockPool{ObjectMeta: metav1.ObjectMeta{Name: "replicapool"}}
+	newPool := oldPool.DeepCopy()
+	newPool.Annotations = map[string]string{PauseReconcileAnnotation: "true"}
+
+	p := WatchControllerPredicate()
+
+	// pausing the reconcile triggers a reconcile to record the paused status
+	e := event.UpdateEvent{ObjectOld: oldPool, MetaOld: oldPool, ObjectNew: newPool, MetaNew: newPool}
+	assert.True(t, p.Update(e))
+
+	// resuming the reconcile catches up with the changes made while paused
+	e = event.UpdateEvent{ObjectOld: newPool, MetaOld: newPool, ObjectNew: oldPool, MetaNew: oldPool}
+	assert.True(t, p.Update(e))
+
+	// other annotation changes are still ignored
+	otherPool := oldPool.DeepCopy()
+	otherPool.Annotations = map[string]string{"foo": "bar"}
+	e = event.UpdateEvent{ObjectOld: oldPool, MetaOld: oldPool, ObjectNew: otherPool, MetaNew: otherPool}
+	assert.False(t, p.Update(e))
+}
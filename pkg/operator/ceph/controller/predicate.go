@@ -40,6 +40,8 @@ const (
 	// Unfortunately this is a duplicate of the const EndpointConfigMapName in the mon package, but done to avoid import cycle
 	endpointConfigMapName   = "rook-ceph-mon-endpoints"
 	doNotReconcileLabelName = "do_not_reconcile"
+	// Unfortunately this is a duplicate of the const RestoreQuorumAnnotation in the mon package, but done to avoid import cycle
+	restoreQuorumAnnotation = "ceph.rook.io/restore-mon-quorum"
 )
 
 // WatchControllerPredicate is a special update filter for update events
@@ -59,6 +61,12 @@ func WatchControllerPredicate() predicate.Funcs {
 		},
 		UpdateFunc: func(e event.UpdateEvent) bool {
 			logger.Debug("update event from a CR")
+			// Reconcile when the reconcile is paused or resumed so that the status reflects the pause
+			// and so that a resumed CR catches up with the changes made while it was paused
+			if isReconcilePausedChanged(e.MetaOld, e.MetaNew) {
+				logger.Infof("reconcile of %q was paused or resumed with the %q annotation", e.MetaNew.GetName(), PauseReconcileAnnotation)
+				return true
+			}
 			// resource.Quantity has non-exportable fields, so we use its comparator method
 			resourceQtyComparer := cmp.Comparer(func(x, y resource.Quantity) bool { return x.Cmp(y) == 0 })
 
@@ -263,6 +271,30 @@ func WatchControllerPredicate() predicate.Funcs {
 					return true
 				}
 
+			case *cephv1.CephOSDRemoval:
+				objNew := e.ObjectNew.(*cephv1.CephOSDRemoval)
+				logger.Debug("update event on CephOSDRemoval CR")
+				diff := cmp.Diff(objOld.Spec, objNew.Spec)
+				if diff != "" {
+					logger.Infof("CR has changed for %q. diff=%s", objNew.Name, diff)
+					return true
+				} else if objOld.GetDeletionTimestamp() != objNew.GetDeletionTimestamp() {
+					logger.Debugf("CR %q is going be deleted", objNew.Name)
+					return true
+				}
+
+			case *cephv1.CephDashboardUser:
+				objNew := e.ObjectNew.(*cephv1.CephDashboardUser)
+				logger.Debug("update event on CephDashboardUser CR")
+				diff := cmp.Diff(objOld.Spec, objNew.Spec)
+				if diff != "" {
+					logger.Infof("CR has changed for %q. diff=%s", objNew.Name, diff)
+					return true
+				} else if objOld.GetDeletionTimestamp() != objNew.GetDeletionTimestamp() {
+					logger.Debugf("CR %q is going be deleted", objNew.Name)
+					return true
+				}
+
 			case *cephv1.CephCluster:
 				objNew := e.ObjectNew.(*cephv1.CephCluster)
 				logger.Debug("update event on CephCluster CR")
@@ -272,6 +304,13 @@ func WatchControllerPredicate() predicate.Funcs {
 					logger.Debugf("object %q matched on update but %q label is set, doing nothing", doNotReconcileLabelName, objNew.Name)
 					return false
 				}
+				// The mon quorum restore is requested with an annotation
+				if _, ok := objNew.GetAnnotations()[restoreQuorumAnnotation]; ok {
+					if _, ok := objOld.GetAnnotations()[restoreQuorumAnnotation]; !ok {
+						logger.Infof("mon quorum restore requested for %q", objNew.Name)
+						return true
+					}
+				}
 				diff := cmp.Diff(objOld.Spec, objNew.Spec, resourceQtyComparer)
 				if diff != "" {
 					logger.Infof("CR has changed for %q. diff=%s", objNew.Name, diff)
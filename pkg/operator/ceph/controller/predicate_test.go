@@ -26,6 +26,7 @@ import (
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/event"
 )
 
 var (
@@ -234,3 +235,19 @@ func TestIsDoNotReconcile(t *testing.T) {
 	b = isDoNotReconcile(l)
 	assert.True(t, b)
 }
+
+func TestRestoreQuorumAnnotationTriggersReconcile(t *testing.T) {
+	oldCluster := &cephv1.CephCluster{ObjectMeta: metav1.ObjectMeta{Name: "rook-ceph"}}
+	newCluster := oldCluster.DeepCopy()
+	newCluster.Annotations = map[string]string{restoreQuorumAnnotation: "b"}
+
+	p := WatchControllerPredicate()
+
+	// requesting the restore triggers a reconcile
+	e := event.UpdateEvent{ObjectOld: oldCluster, MetaOld: oldCluster, ObjectNew: newCluster, MetaNew: newCluster}
+	assert.True(t, p.Update(e))
+
+	// removing the annotation after the restore does not
+	e = event.UpdateEvent{ObjectOld: newCluster, MetaOld: newCluster, ObjectNew: oldCluster, MetaNew: oldCluster}
+	assert.False(t, p.Update(e))
+}
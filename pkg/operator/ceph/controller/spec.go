@@ -506,6 +506,11 @@ func getDaemonConfig(daemonType, daemonID string) *daemonConfig {
 	}
 }
 
+// DaemonAdminSocketPath returns the path of the admin socket of a daemon inside its pod
+func DaemonAdminSocketPath(daemonType, daemonID string) string {
+	return getDaemonConfig(daemonType, daemonID).buildSocketPath()
+}
+
 func (c *daemonConfig) buildSocketName() string {
 	return fmt.Sprintf("ceph-%s.%s.asok", c.daemonType, c.daemonID)
 }
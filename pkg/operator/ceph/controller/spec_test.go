@@ -136,6 +136,10 @@ func TestBuildSocketPath(t *testing.T) {
 	assert.Equal(t, "/run/ceph/ceph-osd.0.asok", socketPath)
 }
 
+func TestDaemonAdminSocketPath(t *testing.T) {
+	assert.Equal(t, "/run/ceph/ceph-mon.a.asok", DaemonAdminSocketPath(config.MonType, "a"))
+}
+
 func TestGenerateLivenessProbeExecDaemon(t *testing.T) {
 	daemonID := "0"
 	probe := GenerateLivenessProbeExecDaemon(config.OsdType, daemonID)
@@ -19,7 +19,9 @@ package operator
 import (
 	"github.com/pkg/errors"
 	"github.com/rook/rook/pkg/operator/ceph/cluster"
+	opcontroller "github.com/rook/rook/pkg/operator/ceph/controller"
 	"github.com/rook/rook/pkg/operator/ceph/disruption/controllerconfig"
+	"github.com/rook/rook/pkg/operator/k8sutil"
 
 	"sigs.k8s.io/controller-runtime/pkg/client/config"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
@@ -27,7 +29,8 @@ import (
 
 func (o *Operator) startManager(namespaceToWatch string, stopCh <-chan struct{},
 	mgrErrorCh chan error) {
-	// Set up a manager
+	// Set up a manager. Leader election is not needed here since the whole operator only runs
+	// once it holds the leader lease (see RunWithLeaderElection).
 	mgrOpts := manager.Options{
 		LeaderElection: false,
 		Namespace:      namespaceToWatch,
@@ -39,6 +42,9 @@ func (o *Operator) startManager(namespaceToWatch string, stopCh <-chan struct{},
 		mgrErrorCh <- errors.Wrap(err, "failed to get client config for controller-runtime manager")
 		return
 	}
+	if opcontroller.DryRun {
+		kubeConfig.Wrap(k8sutil.WrapDryRunTransport)
+	}
 
 	mgr, err := manager.New(kubeConfig, mgrOpts)
 	if err != nil {
@@ -0,0 +1,128 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package operator
+
+import (
+	"context"
+	"os"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rook/rook/pkg/operator/k8sutil"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/leaderelection"
+	"k8s.io/client-go/tools/leaderelection/resourcelock"
+)
+
+const (
+	// leaderElectionLeaseName is the name of the lease held by the active operator replica
+	leaderElectionLeaseName = "rook-ceph-operator-lock"
+)
+
+var (
+	// EnableLeaderElection Whether the operator must hold the leader lease before running. If true,
+	// multiple replicas of the operator can run and only one of them is active at any time.
+	EnableLeaderElection = false
+
+	// LeaderElectionLeaseDuration is the duration standby replicas wait before taking over the lease
+	LeaderElectionLeaseDuration = 15 * time.Second
+
+	// LeaderElectionRenewDeadline is the duration the leader retries renewing the lease before giving up
+	LeaderElectionRenewDeadline = 10 * time.Second
+
+	// LeaderElectionRetryPeriod is the duration between attempts to acquire or renew the lease
+	LeaderElectionRetryPeriod = 2 * time.Second
+)
+
+// RunWithLeaderElection runs the operator once this replica holds the leader lease in the operator
+// namespace. Standby replicas block until the lease is released or expires. If the leader loses
+// the lease the process exits so that none of its controllers keep running next to the new leader.
+// If leader election is disabled, the operator is run right away.
+func (o *Operator) RunWithLeaderElection() error {
+	if !EnableLeaderElection {
+		return o.Run()
+	}
+	if o.operatorNamespace == "" {
+		return errors.Errorf("rook operator namespace is not provided. expose it via downward API in the rook operator manifest file using environment variable %q", k8sutil.PodNamespaceEnvVar)
+	}
+
+	identity, err := leaderElectionIdentity()
+	if err != nil {
+		return err
+	}
+
+	lock := &resourcelock.LeaseLock{
+		LeaseMeta: metav1.ObjectMeta{
+			Name:      leaderElectionLeaseName,
+			Namespace: o.operatorNamespace,
+		},
+		Client: o.context.Clientset.CoordinationV1(),
+		LockConfig: resourcelock.ResourceLockConfig{
+			Identity: identity,
+		},
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var runErr error
+	done := make(chan struct{})
+	logger.Infof("operator %q waiting to acquire the leader lease %q", identity, leaderElectionLeaseName)
+	leaderelection.RunOrDie(ctx, leaderelection.LeaderElectionConfig{
+		Lock:            lock,
+		LeaseDuration:   LeaderElectionLeaseDuration,
+		RenewDeadline:   LeaderElectionRenewDeadline,
+		RetryPeriod:     LeaderElectionRetryPeriod,
+		ReleaseOnCancel: true,
+		Callbacks: leaderelection.LeaderCallbacks{
+			OnStartedLeading: func(ctx context.Context) {
+				logger.Infof("operator %q acquired the leader lease, starting the operator", identity)
+				runErr = o.Run()
+				close(done)
+				// release the lease so a standby replica can take over right away
+				cancel()
+			},
+			OnStoppedLeading: func() {
+				select {
+				case <-done:
+					logger.Infof("operator %q released the leader lease", identity)
+				default:
+					logger.Fatalf("operator %q lost the leader lease, exiting", identity)
+				}
+			},
+			OnNewLeader: func(current string) {
+				if current != identity {
+					logger.Infof("operator %q is the leader, running in standby", current)
+				}
+			},
+		},
+	})
+
+	return runErr
+}
+
+// leaderElectionIdentity returns the identity of this operator replica in the leader election
+func leaderElectionIdentity() (string, error) {
+	if podName := os.Getenv(k8sutil.PodNameEnvVar); podName != "" {
+		return podName, nil
+	}
+	hostname, err := os.Hostname()
+	if err != nil {
+		return "", errors.Wrap(err, "failed to get the leader election identity")
+	}
+	return hostname, nil
+}
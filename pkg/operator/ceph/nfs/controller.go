@@ -77,7 +77,7 @@ type ReconcileCephNFS struct {
 // Add creates a new cephNFS Controller and adds it to the Manager. The Manager will set fields on the Controller
 // and Start it when the Manager is Started.
 func Add(mgr manager.Manager, context *clusterd.Context) error {
-	return add(mgr, newReconciler(mgr, context))
+	return add(mgr, newReconciler(mgr, context), context)
 }
 
 // newReconciler returns a new reconcile.Reconciler
@@ -95,9 +95,9 @@ func newReconciler(mgr manager.Manager, context *clusterd.Context) reconcile.Rec
 	}
 }
 
-func add(mgr manager.Manager, r reconcile.Reconciler) error {
+func add(mgr manager.Manager, r reconcile.Reconciler, context *clusterd.Context) error {
 	// Create a new controller
-	c, err := controller.New(controllerName, mgr, controller.Options{Reconciler: r})
+	c, err := controller.New(controllerName, mgr, opcontroller.SerialControllerOptions(context, controllerName, r))
 	if err != nil {
 		return err
 	}
@@ -150,6 +150,13 @@ func (r *ReconcileCephNFS) reconcile(request reconcile.Request) (reconcile.Resul
 		return reconcile.Result{}, errors.Wrap(err, "failed to get cephNFS")
 	}
 
+	// Do nothing while the reconcile is paused, not even the deletion
+	if opcontroller.IsReconcilePaused(cephNFS) {
+		logger.Infof("reconcile of CephNFS %q is paused with the %q annotation, skipping", request.NamespacedName, opcontroller.PauseReconcileAnnotation)
+		updateStatus(r.client, request.NamespacedName, k8sutil.PausedStatus)
+		return reconcile.Result{}, nil
+	}
+
 	// The CR was just created, initializing status fields
 	if cephNFS.Status == nil {
 		updateStatus(r.client, request.NamespacedName, k8sutil.Created)
@@ -18,11 +18,13 @@ package operator
 
 import (
 	"fmt"
+	"os"
 	"testing"
 
 	"github.com/rook/rook/pkg/clusterd"
 	"github.com/rook/rook/pkg/daemon/ceph/agent/flexvolume/attachment"
 	opcontroller "github.com/rook/rook/pkg/operator/ceph/controller"
+	"github.com/rook/rook/pkg/operator/k8sutil"
 	"github.com/rook/rook/pkg/operator/test"
 	"github.com/stretchr/testify/assert"
 )
@@ -43,3 +45,18 @@ func TestOperator(t *testing.T) {
 		}
 	}
 }
+
+func TestLeaderElectionIdentity(t *testing.T) {
+	os.Setenv(k8sutil.PodNameEnvVar, "rook-ceph-operator-abc")
+	defer os.Unsetenv(k8sutil.PodNameEnvVar)
+	identity, err := leaderElectionIdentity()
+	assert.NoError(t, err)
+	assert.Equal(t, "rook-ceph-operator-abc", identity)
+
+	// fall back to the hostname when the pod name is not exposed
+	os.Unsetenv(k8sutil.PodNameEnvVar)
+	hostname, _ := os.Hostname()
+	identity, err = leaderElectionIdentity()
+	assert.NoError(t, err)
+	assert.Equal(t, hostname, identity)
+}
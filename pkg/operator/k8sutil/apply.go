@@ -0,0 +1,149 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package k8sutil
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	apps "k8s.io/api/apps/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+)
+
+// FieldManager is the name rook uses to claim ownership of the fields it sets when applying
+// resources server-side. Fields set by any other manager (users, other controllers) are left alone.
+const FieldManager = "rook-ceph-operator"
+
+// updateFieldManager is the manager the API server records for the creations and updates made by
+// the operator without server-side apply, the name of the operator binary in its user agent
+var updateFieldManager = strings.Split(rest.DefaultKubernetesUserAgent(), "/")[0]
+
+// errApplyNotSupported is returned when the server or the client cannot apply resources
+// server-side, i.e. Kubernetes older than 1.16 or the fake clientset of the unit tests
+var errApplyNotSupported = errors.New("server-side apply is not supported")
+
+// ApplyDeployment creates or updates a deployment with server-side apply. Only the fields set in
+// the given deployment are owned by rook, so changes made to other fields by users or other
+// controllers (e.g. custom annotations) are no longer reverted on the next reconcile.
+// Server-side apply requires Kubernetes 1.16 or newer.
+func ApplyDeployment(clientset kubernetes.Interface, d *apps.Deployment) (*apps.Deployment, error) {
+	data, err := deploymentApplyConfiguration(d)
+	if err != nil {
+		return nil, err
+	}
+
+	restClient, ok := clientset.AppsV1().RESTClient().(*rest.RESTClient)
+	if !ok || restClient == nil {
+		return nil, errApplyNotSupported
+	}
+
+	result := &apps.Deployment{}
+	err = restClient.Patch(types.ApplyPatchType).
+		Namespace(d.Namespace).
+		Resource("deployments").
+		Name(d.Name).
+		Param("fieldManager", FieldManager).
+		Param("force", "true").
+		Body(data).
+		Do().
+		Into(result)
+	if kerrors.IsUnsupportedMediaType(err) {
+		return nil, errApplyNotSupported
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to apply deployment %q. %v", d.Name, err)
+	}
+	return result, nil
+}
+
+// applyOrUpdateDeployment applies the deployment server-side, or updates it if server-side apply
+// is not supported. The update replaces the whole deployment, reverting the changes made by others.
+// The fields of the current deployment set by the updates of the operator are first moved to the
+// apply field manager so they are removed once the operator no longer applies them.
+func applyOrUpdateDeployment(clientset kubernetes.Interface, current, d *apps.Deployment) (*apps.Deployment, error) {
+	if managedFields, ok := takeOverUpdatedFields(current.ManagedFields); ok {
+		logger.Infof("moving the fields of deployment %q set by %q to the apply field manager %q", current.Name, updateFieldManager, FieldManager)
+		updated := current.DeepCopy()
+		updated.ManagedFields = managedFields
+		if _, err := clientset.AppsV1().Deployments(current.Namespace).Update(updated); err != nil {
+			return nil, fmt.Errorf("failed to move the managed fields of deployment %q. %v", current.Name, err)
+		}
+	}
+
+	result, err := ApplyDeployment(clientset, d)
+	if err != errApplyNotSupported {
+		return result, err
+	}
+	logger.Debugf("%v, updating deployment %q", err, d.Name)
+	result, err = clientset.AppsV1().Deployments(d.Namespace).Update(d)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update deployment %q. %v", d.Name, err)
+	}
+	return result, nil
+}
+
+// takeOverUpdatedFields returns the managed fields with the fields of the apps/v1 deployment set by
+// the updates of the operator owned by the apply field manager instead. Server-side apply only removes the fields dropped from
+// an applied object if the apply field manager owns them, the fields of the deployments created or
+// updated by the operator before it applied them would never be removed otherwise. It returns false
+// if the apply field manager already owns fields, the ownership was then already moved on the first
+// apply, or if there are no fields set by the operator.
+func takeOverUpdatedFields(managedFields []metav1.ManagedFieldsEntry) ([]metav1.ManagedFieldsEntry, bool) {
+	for _, entry := range managedFields {
+		if entry.Manager == FieldManager && entry.Operation == metav1.ManagedFieldsOperationApply {
+			return nil, false
+		}
+	}
+
+	moved := false
+	result := []metav1.ManagedFieldsEntry{}
+	for _, entry := range managedFields {
+		entry := *entry.DeepCopy()
+		if entry.Manager == updateFieldManager && entry.Operation == metav1.ManagedFieldsOperationUpdate &&
+			entry.APIVersion == apps.SchemeGroupVersion.String() {
+			entry.Manager = FieldManager
+			entry.Operation = metav1.ManagedFieldsOperationApply
+			entry.Time = nil
+			moved = true
+		}
+		result = append(result, entry)
+	}
+	return result, moved
+}
+
+// deploymentApplyConfiguration returns the body of an apply patch for the deployment. The type
+// information is required by the API server and the fields that are owned by the server are removed.
+func deploymentApplyConfiguration(d *apps.Deployment) ([]byte, error) {
+	applied := d.DeepCopy()
+	applied.APIVersion = apps.SchemeGroupVersion.String()
+	applied.Kind = "Deployment"
+	applied.ResourceVersion = ""
+	applied.ManagedFields = nil
+	applied.Status = apps.DeploymentStatus{}
+
+	data, err := json.Marshal(applied)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal deployment %q. %v", d.Name, err)
+	}
+	return data, nil
+}
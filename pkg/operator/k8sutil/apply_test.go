@@ -0,0 +1,192 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package k8sutil
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	apps "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/rest"
+)
+
+func TestDeploymentApplyConfiguration(t *testing.T) {
+	d := &apps.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            "rook-ceph-mgr-a",
+			Namespace:       "rook-ceph",
+			ResourceVersion: "1234",
+			ManagedFields:   []metav1.ManagedFieldsEntry{{Manager: "kubectl"}},
+		},
+		Status: apps.DeploymentStatus{ReadyReplicas: 1},
+	}
+
+	data, err := deploymentApplyConfiguration(d)
+	assert.NoError(t, err)
+
+	applied := &apps.Deployment{}
+	assert.NoError(t, json.Unmarshal(data, applied))
+	assert.Equal(t, "apps/v1", applied.APIVersion)
+	assert.Equal(t, "Deployment", applied.Kind)
+	assert.Equal(t, "rook-ceph-mgr-a", applied.Name)
+	assert.Equal(t, "rook-ceph", applied.Namespace)
+	assert.Equal(t, "", applied.ResourceVersion)
+	assert.Nil(t, applied.ManagedFields)
+	assert.Equal(t, int32(0), applied.Status.ReadyReplicas)
+
+	// the original deployment is not modified
+	assert.Equal(t, "", d.Kind)
+	assert.Equal(t, "1234", d.ResourceVersion)
+	assert.Equal(t, int32(1), d.Status.ReadyReplicas)
+}
+
+// apiRequest is a request received by the test API server
+type apiRequest struct {
+	method      string
+	path        string
+	contentType string
+	query       map[string]string
+	body        []byte
+}
+
+// newTestAPIServer returns a clientset sending its requests to a test API server, which answers
+// the apply patches with the given status code and the other requests with the received object
+func newTestAPIServer(t *testing.T, applyStatus int) (kubernetes.Interface, *[]apiRequest, func()) {
+	requests := []apiRequest{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := ioutil.ReadAll(r.Body)
+		assert.NoError(t, err)
+		requests = append(requests, apiRequest{
+			method:      r.Method,
+			path:        r.URL.Path,
+			contentType: r.Header.Get("Content-Type"),
+			query:       map[string]string{"fieldManager": r.URL.Query().Get("fieldManager"), "force": r.URL.Query().Get("force")},
+			body:        body,
+		})
+		w.Header().Set("Content-Type", "application/json")
+		if r.Method == http.MethodPatch && applyStatus != http.StatusOK {
+			w.WriteHeader(applyStatus)
+			_, _ = w.Write([]byte(`{"kind":"Status","apiVersion":"v1","status":"Failure","reason":"UnsupportedMediaType","code":415}`))
+			return
+		}
+		_, _ = w.Write(body)
+	}))
+	clientset, err := kubernetes.NewForConfig(&rest.Config{Host: server.URL})
+	require.NoError(t, err)
+	return clientset, &requests, server.Close
+}
+
+func TestApplyDeployment(t *testing.T) {
+	clientset, requests, closeServer := newTestAPIServer(t, http.StatusOK)
+	defer closeServer()
+	d := &apps.Deployment{ObjectMeta: metav1.ObjectMeta{Name: "rook-ceph-mon-a", Namespace: "rook-ceph", ResourceVersion: "12"}}
+
+	result, err := applyOrUpdateDeployment(clientset, d, d)
+	assert.NoError(t, err)
+	assert.Equal(t, "rook-ceph-mon-a", result.Name)
+
+	// a single apply patch owned by the operator
+	require.Equal(t, 1, len(*requests))
+	request := (*requests)[0]
+	assert.Equal(t, http.MethodPatch, request.method)
+	assert.Equal(t, "/apis/apps/v1/namespaces/rook-ceph/deployments/rook-ceph-mon-a", request.path)
+	assert.Equal(t, "application/apply-patch+yaml", request.contentType)
+	assert.Equal(t, FieldManager, request.query["fieldManager"])
+	assert.Equal(t, "true", request.query["force"])
+	applied := &apps.Deployment{}
+	assert.NoError(t, json.Unmarshal(request.body, applied))
+	assert.Equal(t, "Deployment", applied.Kind)
+	assert.Equal(t, "", applied.ResourceVersion)
+
+	// the fields set by the updates of the operator are moved to the apply manager before the first apply
+	*requests = []apiRequest{}
+	current := d.DeepCopy()
+	current.ManagedFields = []metav1.ManagedFieldsEntry{{Manager: updateFieldManager, Operation: metav1.ManagedFieldsOperationUpdate, APIVersion: "apps/v1"}}
+	_, err = applyOrUpdateDeployment(clientset, current, d)
+	assert.NoError(t, err)
+	require.Equal(t, 2, len(*requests))
+	assert.Equal(t, http.MethodPut, (*requests)[0].method)
+	updated := &apps.Deployment{}
+	assert.NoError(t, json.Unmarshal((*requests)[0].body, updated))
+	assert.Equal(t, FieldManager, updated.ManagedFields[0].Manager)
+	assert.Equal(t, http.MethodPatch, (*requests)[1].method)
+}
+
+func TestTakeOverUpdatedFields(t *testing.T) {
+	now := metav1.Now()
+	managedFields := []metav1.ManagedFieldsEntry{
+		{Manager: updateFieldManager, Operation: metav1.ManagedFieldsOperationUpdate, APIVersion: "apps/v1", Time: &now},
+		{Manager: "kubectl", Operation: metav1.ManagedFieldsOperationUpdate, APIVersion: "apps/v1"},
+		{Manager: "kube-controller-manager", Operation: metav1.ManagedFieldsOperationUpdate, APIVersion: "apps/v1"},
+	}
+
+	// only the fields of the operator are moved
+	result, ok := takeOverUpdatedFields(managedFields)
+	assert.True(t, ok)
+	require.Equal(t, 3, len(result))
+	assert.Equal(t, FieldManager, result[0].Manager)
+	assert.Equal(t, metav1.ManagedFieldsOperationApply, result[0].Operation)
+	assert.Nil(t, result[0].Time)
+	assert.Equal(t, "kubectl", result[1].Manager)
+	assert.Equal(t, updateFieldManager, managedFields[0].Manager)
+
+	// nothing is moved once the operator applied the deployment
+	_, ok = takeOverUpdatedFields(result)
+	assert.False(t, ok)
+
+	// nothing is moved without fields set by the operator
+	_, ok = takeOverUpdatedFields(managedFields[1:])
+	assert.False(t, ok)
+	_, ok = takeOverUpdatedFields(nil)
+	assert.False(t, ok)
+}
+
+func TestApplyDeploymentNotSupported(t *testing.T) {
+	// the deployment is updated when the server doesn't support server-side apply
+	clientset, requests, closeServer := newTestAPIServer(t, http.StatusUnsupportedMediaType)
+	defer closeServer()
+	d := &apps.Deployment{ObjectMeta: metav1.ObjectMeta{Name: "rook-ceph-mon-a", Namespace: "rook-ceph"}}
+
+	_, err := ApplyDeployment(clientset, d)
+	assert.Equal(t, errApplyNotSupported, err)
+
+	*requests = []apiRequest{}
+	_, err = applyOrUpdateDeployment(clientset, d, d)
+	assert.NoError(t, err)
+	require.Equal(t, 2, len(*requests))
+	assert.Equal(t, http.MethodPatch, (*requests)[0].method)
+	assert.Equal(t, http.MethodPut, (*requests)[1].method)
+
+	// the fake clientset cannot apply
+	fakeClientset := fake.NewSimpleClientset(d)
+	_, err = ApplyDeployment(fakeClientset, d)
+	assert.Equal(t, errApplyNotSupported, err)
+	d.Labels = map[string]string{"a": "b"}
+	_, err = applyOrUpdateDeployment(fakeClientset, d, d)
+	assert.NoError(t, err)
+	updated, err := fakeClientset.AppsV1().Deployments("rook-ceph").Get("rook-ceph-mon-a", metav1.GetOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, "b", updated.Labels["a"])
+}
@@ -91,8 +91,11 @@ func UpdateDeploymentAndWait(context *clusterd.Context, modifiedDeployment *apps
 			return nil, fmt.Errorf("failed to set hash annotation on deployment %q. %v", modifiedDeployment.Name, err)
 		}
 
-		if _, err := context.Clientset.AppsV1().Deployments(namespace).Update(modifiedDeployment); err != nil {
-			return nil, fmt.Errorf("failed to update deployment %q. %v", modifiedDeployment.Name, err)
+		// the deployment is applied server-side so the fields set by others, such as the
+		// annotations added by users, are not reverted
+		modifiedDeployment.Namespace = namespace
+		if _, err := applyOrUpdateDeployment(context.Clientset, currentDeployment, modifiedDeployment); err != nil {
+			return nil, err
 		}
 
 		// wait for the deployment to be restarted
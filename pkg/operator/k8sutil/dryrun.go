@@ -0,0 +1,137 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package k8sutil
+
+import (
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// DryRunChanges records the changes the operator would have applied when running in dry-run mode
+var DryRunChanges = NewDryRunRecorder()
+
+// maxDryRunChanges is the number of changes kept per namespace. Only the changes of the CephCluster
+// namespaces are flushed when reported, so the changes of the other namespaces, of the cluster
+// resources and of the commands run without a cluster must not grow forever.
+const maxDryRunChanges = 100
+
+// DryRunRecorder records the latest planned changes of the operator per namespace
+type DryRunRecorder struct {
+	mutex   sync.Mutex
+	changes map[string][]string
+}
+
+// NewDryRunRecorder returns an empty DryRunRecorder
+func NewDryRunRecorder() *DryRunRecorder {
+	return &DryRunRecorder{changes: map[string][]string{}}
+}
+
+// Record records a change planned in the given namespace
+func (r *DryRunRecorder) Record(namespace, change string) {
+	logger.Infof("dry-run: would %s", change)
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	changes := append(r.changes[namespace], change)
+	if len(changes) > maxDryRunChanges {
+		changes = changes[len(changes)-maxDryRunChanges:]
+	}
+	r.changes[namespace] = changes
+}
+
+// Flush returns the changes planned in the given namespace since the last flush
+func (r *DryRunRecorder) Flush(namespace string) []string {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	changes := r.changes[namespace]
+	delete(r.changes, namespace)
+	return changes
+}
+
+// WrapDryRunTransport wraps a transport so that the requests changing resources are sent to the API
+// server in dry-run mode. The server validates and admits them as usual but does not persist them.
+// The planned changes are recorded in DryRunChanges. This requires Kubernetes 1.13 or newer.
+// Events, leases and the status of the rook CRs are still updated so that the operator can run
+// and report the planned changes.
+func WrapDryRunTransport(rt http.RoundTripper) http.RoundTripper {
+	return &dryRunTransport{delegate: rt, recorder: DryRunChanges}
+}
+
+type dryRunTransport struct {
+	delegate http.RoundTripper
+	recorder *DryRunRecorder
+}
+
+func (t *dryRunTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	verb := dryRunVerb(req.Method)
+	if verb == "" || !dryRunApplies(req.URL.Path) {
+		return t.delegate.RoundTrip(req)
+	}
+
+	// a RoundTripper must not modify the original request
+	dryRunReq := req.Clone(req.Context())
+	query := dryRunReq.URL.Query()
+	query.Set("dryRun", "All")
+	dryRunReq.URL.RawQuery = query.Encode()
+
+	t.recorder.Record(namespaceFromPath(req.URL.Path), verb+" "+req.URL.Path)
+	return t.delegate.RoundTrip(dryRunReq)
+}
+
+// dryRunVerb returns the verb of a request that changes a resource, or an empty string
+func dryRunVerb(method string) string {
+	switch method {
+	case http.MethodPost:
+		return "create"
+	case http.MethodPut:
+		return "update"
+	case http.MethodPatch:
+		return "patch"
+	case http.MethodDelete:
+		return "delete"
+	}
+	return ""
+}
+
+// dryRunApplies returns whether the request for the given path must be run in dry-run mode
+func dryRunApplies(path string) bool {
+	// connecting to pods is not supported in dry-run mode
+	for _, subresource := range []string{"/exec", "/attach", "/portforward", "/proxy"} {
+		if strings.HasSuffix(path, subresource) {
+			return false
+		}
+	}
+	if strings.Contains(path, "/events") || strings.HasPrefix(path, "/apis/coordination.k8s.io/") {
+		return false
+	}
+	// the operator reports the planned changes in the status of the rook CRs
+	if strings.HasPrefix(path, "/apis/ceph.rook.io/") && strings.HasSuffix(path, "/status") {
+		return false
+	}
+	return true
+}
+
+// namespaceFromPath returns the namespace of an API path, or an empty string for a cluster resource
+func namespaceFromPath(path string) string {
+	parts := strings.Split(path, "/")
+	for i, part := range parts {
+		if part == "namespaces" && i+1 < len(parts) {
+			return parts[i+1]
+		}
+	}
+	return ""
+}
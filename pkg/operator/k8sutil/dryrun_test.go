@@ -0,0 +1,74 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package k8sutil
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDryRunTransport(t *testing.T) {
+	var dryRun []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		dryRun = append(dryRun, r.URL.Query().Get("dryRun"))
+	}))
+	defer server.Close()
+
+	recorder := NewDryRunRecorder()
+	client := &http.Client{Transport: &dryRunTransport{delegate: http.DefaultTransport, recorder: recorder}}
+	request := func(method, path string) {
+		req, err := http.NewRequest(method, server.URL+path, nil)
+		assert.NoError(t, err)
+		resp, err := client.Do(req)
+		assert.NoError(t, err)
+		resp.Body.Close()
+	}
+
+	// reads are not changed
+	request(http.MethodGet, "/apis/apps/v1/namespaces/rook-ceph/deployments/rook-ceph-mgr-a")
+	// changes are sent in dry-run mode
+	request(http.MethodPut, "/apis/apps/v1/namespaces/rook-ceph/deployments/rook-ceph-mgr-a")
+	request(http.MethodDelete, "/api/v1/namespaces/rook-ceph/configmaps/foo")
+	// the status of the CRs is still updated
+	request(http.MethodPut, "/apis/ceph.rook.io/v1/namespaces/rook-ceph/cephclusters/rook-ceph/status")
+
+	assert.Equal(t, []string{"", "All", "All", ""}, dryRun)
+	assert.Equal(t, []string{
+		"update /apis/apps/v1/namespaces/rook-ceph/deployments/rook-ceph-mgr-a",
+		"delete /api/v1/namespaces/rook-ceph/configmaps/foo",
+	}, recorder.Flush("rook-ceph"))
+	assert.Nil(t, recorder.Flush("rook-ceph"))
+}
+
+func TestDryRunRecorderBound(t *testing.T) {
+	recorder := NewDryRunRecorder()
+	for i := 0; i < maxDryRunChanges+10; i++ {
+		recorder.Record("", fmt.Sprintf("create /api/v1/nodes/node%d", i))
+	}
+	changes := recorder.Flush("")
+	assert.Equal(t, maxDryRunChanges, len(changes))
+	assert.Equal(t, "create /api/v1/nodes/node10", changes[0])
+}
+
+func TestNamespaceFromPath(t *testing.T) {
+	assert.Equal(t, "rook-ceph", namespaceFromPath("/api/v1/namespaces/rook-ceph/pods"))
+	assert.Equal(t, "", namespaceFromPath("/api/v1/nodes/node1"))
+}
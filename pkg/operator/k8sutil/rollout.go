@@ -0,0 +1,246 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package k8sutil
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/pkg/errors"
+	apps "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/kubernetes"
+)
+
+// RolloutProgress is the progress of a Deployment, DaemonSet or StatefulSet rollout
+type RolloutProgress struct {
+	// Desired is the number of pods that should be running once the rollout is complete
+	Desired int32
+	// Updated is the number of pods running the latest spec
+	Updated int32
+	// Ready is the number of pods that are ready
+	Ready int32
+	// StuckReason is set when the rollout can no longer make progress on its own
+	StuckReason string
+}
+
+// String returns a short human readable summary of the rollout progress
+func (p RolloutProgress) String() string {
+	s := fmt.Sprintf("%d/%d updated, %d/%d ready", p.Updated, p.Desired, p.Ready, p.Desired)
+	if p.StuckReason != "" {
+		s = fmt.Sprintf("%s, stuck: %s", s, p.StuckReason)
+	}
+	return s
+}
+
+// WaitForRollout watches the rollout of a Deployment, DaemonSet or StatefulSet until all of its
+// pods are updated and ready. The onProgress callback (if not nil) is invoked every time the
+// progress of the rollout changes so callers can surface it, for example in the CR status.
+// An error is returned if the rollout is stuck, the object is deleted or the context is done.
+func WaitForRollout(ctx context.Context, clientset kubernetes.Interface, obj runtime.Object, onProgress func(RolloutProgress)) error {
+	var name, namespace string
+	var watchFunc func(metav1.ListOptions) (watch.Interface, error)
+	var getFunc func() (runtime.Object, error)
+	switch o := obj.(type) {
+	case *apps.Deployment:
+		name, namespace = o.Name, o.Namespace
+		watchFunc = clientset.AppsV1().Deployments(namespace).Watch
+		getFunc = func() (runtime.Object, error) {
+			return clientset.AppsV1().Deployments(namespace).Get(name, metav1.GetOptions{})
+		}
+	case *apps.DaemonSet:
+		name, namespace = o.Name, o.Namespace
+		watchFunc = clientset.AppsV1().DaemonSets(namespace).Watch
+		getFunc = func() (runtime.Object, error) {
+			return clientset.AppsV1().DaemonSets(namespace).Get(name, metav1.GetOptions{})
+		}
+	case *apps.StatefulSet:
+		name, namespace = o.Name, o.Namespace
+		watchFunc = clientset.AppsV1().StatefulSets(namespace).Watch
+		getFunc = func() (runtime.Object, error) {
+			return clientset.AppsV1().StatefulSets(namespace).Get(name, metav1.GetOptions{})
+		}
+	default:
+		return errors.Errorf("cannot wait for the rollout of unsupported type %T", obj)
+	}
+
+	var last *RolloutProgress
+	// checkProgress reports the progress of the given object and returns true when the rollout is complete
+	checkProgress := func(o runtime.Object) (bool, error) {
+		progress, complete := rolloutProgress(o)
+		if last == nil || *last != progress {
+			logger.Debugf("rollout of %q: %s", name, progress.String())
+			if onProgress != nil {
+				onProgress(progress)
+			}
+			last = &progress
+		}
+		if progress.StuckReason != "" {
+			return false, errors.Errorf("rollout of %q is stuck. %s", name, progress.StuckReason)
+		}
+		return complete, nil
+	}
+
+	for {
+		// start watching before getting the current state so no update can be missed in between
+		w, err := watchFunc(metav1.ListOptions{FieldSelector: fields.OneTermEqualSelector("metadata.name", name).String()})
+		if err != nil {
+			return errors.Wrapf(err, "failed to watch the rollout of %q", name)
+		}
+
+		current, err := getFunc()
+		if err != nil {
+			w.Stop()
+			return errors.Wrapf(err, "failed to get %q", name)
+		}
+		complete, err := checkProgress(current)
+		if err != nil || complete {
+			w.Stop()
+			return err
+		}
+
+		complete, err = watchRollout(ctx, w, name, checkProgress)
+		w.Stop()
+		if err != nil || complete {
+			return err
+		}
+		logger.Debugf("watch of the rollout of %q was closed, restarting it", name)
+	}
+}
+
+// watchRollout processes the watch events until the rollout is complete, fails, or the watch is closed
+func watchRollout(ctx context.Context, w watch.Interface, name string, checkProgress func(runtime.Object) (bool, error)) (bool, error) {
+	for {
+		select {
+		case <-ctx.Done():
+			return false, errors.Wrapf(ctx.Err(), "stopped waiting for the rollout of %q", name)
+		case event, ok := <-w.ResultChan():
+			if !ok {
+				return false, nil
+			}
+			switch event.Type {
+			case watch.Deleted:
+				return false, errors.Errorf("%q was deleted during its rollout", name)
+			case watch.Error:
+				return false, errors.Errorf("failed to watch the rollout of %q. %v", name, event.Object)
+			case watch.Added, watch.Modified:
+				if objectName(event.Object) != name {
+					continue
+				}
+				complete, err := checkProgress(event.Object)
+				if err != nil || complete {
+					return complete, err
+				}
+			}
+		}
+	}
+}
+
+func objectName(obj runtime.Object) string {
+	switch o := obj.(type) {
+	case *apps.Deployment:
+		return o.Name
+	case *apps.DaemonSet:
+		return o.Name
+	case *apps.StatefulSet:
+		return o.Name
+	}
+	return ""
+}
+
+// rolloutProgress returns the progress of the rollout and whether the rollout is complete
+func rolloutProgress(obj runtime.Object) (RolloutProgress, bool) {
+	switch o := obj.(type) {
+	case *apps.Deployment:
+		return deploymentRolloutProgress(o)
+	case *apps.DaemonSet:
+		return daemonSetRolloutProgress(o)
+	case *apps.StatefulSet:
+		return statefulSetRolloutProgress(o)
+	}
+	return RolloutProgress{}, false
+}
+
+func deploymentRolloutProgress(d *apps.Deployment) (RolloutProgress, bool) {
+	desired := int32(1)
+	if d.Spec.Replicas != nil {
+		desired = *d.Spec.Replicas
+	}
+	progress := RolloutProgress{
+		Desired: desired,
+		Updated: d.Status.UpdatedReplicas,
+		Ready:   d.Status.ReadyReplicas,
+	}
+
+	// the conditions still belong to the previous rollout until the new generation is observed
+	if d.Status.ObservedGeneration < d.Generation {
+		return progress, false
+	}
+	for _, condition := range d.Status.Conditions {
+		if condition.Type == apps.DeploymentProgressing && condition.Reason == "ProgressDeadlineExceeded" {
+			progress.StuckReason = condition.Message
+			if progress.StuckReason == "" {
+				progress.StuckReason = condition.Reason
+			}
+		}
+	}
+	// old pods must be gone and all the updated pods must be available
+	complete := d.Status.UpdatedReplicas == desired &&
+		d.Status.Replicas == desired &&
+		d.Status.AvailableReplicas == desired
+	return progress, complete
+}
+
+func daemonSetRolloutProgress(ds *apps.DaemonSet) (RolloutProgress, bool) {
+	progress := RolloutProgress{
+		Desired: ds.Status.DesiredNumberScheduled,
+		Updated: ds.Status.UpdatedNumberScheduled,
+		Ready:   ds.Status.NumberReady,
+	}
+
+	if ds.Status.ObservedGeneration < ds.Generation {
+		return progress, false
+	}
+	complete := ds.Status.UpdatedNumberScheduled == ds.Status.DesiredNumberScheduled &&
+		ds.Status.NumberAvailable == ds.Status.DesiredNumberScheduled
+	return progress, complete
+}
+
+func statefulSetRolloutProgress(ss *apps.StatefulSet) (RolloutProgress, bool) {
+	desired := int32(1)
+	if ss.Spec.Replicas != nil {
+		desired = *ss.Spec.Replicas
+	}
+	progress := RolloutProgress{
+		Desired: desired,
+		Updated: ss.Status.UpdatedReplicas,
+		Ready:   ss.Status.ReadyReplicas,
+	}
+
+	if ss.Status.ObservedGeneration < ss.Generation {
+		return progress, false
+	}
+	complete := ss.Status.UpdatedReplicas == desired && ss.Status.ReadyReplicas == desired
+	// with the OnDelete strategy pods are only updated when deleted, so the revisions are not compared
+	if ss.Spec.UpdateStrategy.Type != apps.OnDeleteStatefulSetStrategyType {
+		complete = complete && ss.Status.CurrentRevision == ss.Status.UpdateRevision
+	}
+	return progress, complete
+}
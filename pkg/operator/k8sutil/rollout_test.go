@@ -0,0 +1,155 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package k8sutil
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	apps "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func newRolloutTestDeployment(replicas, updated, ready int32) *apps.Deployment {
+	return &apps.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "rook-ceph-mon-a", Namespace: "rook-ceph"},
+		Spec:       apps.DeploymentSpec{Replicas: &replicas},
+		Status: apps.DeploymentStatus{
+			Replicas:          replicas,
+			UpdatedReplicas:   updated,
+			ReadyReplicas:     ready,
+			AvailableReplicas: ready,
+		},
+	}
+}
+
+func TestWaitForRolloutComplete(t *testing.T) {
+	clientset := fake.NewSimpleClientset()
+	d, err := clientset.AppsV1().Deployments("rook-ceph").Create(newRolloutTestDeployment(1, 1, 1))
+	assert.NoError(t, err)
+
+	var progress []RolloutProgress
+	err = WaitForRollout(context.TODO(), clientset, d, func(p RolloutProgress) {
+		progress = append(progress, p)
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []RolloutProgress{{Desired: 1, Updated: 1, Ready: 1}}, progress)
+}
+
+func TestWaitForRolloutProgress(t *testing.T) {
+	clientset := fake.NewSimpleClientset()
+	d, err := clientset.AppsV1().Deployments("rook-ceph").Create(newRolloutTestDeployment(2, 0, 0))
+	assert.NoError(t, err)
+
+	// each time progress is reported, move the rollout one step further
+	var progress []RolloutProgress
+	err = WaitForRollout(context.TODO(), clientset, d, func(p RolloutProgress) {
+		progress = append(progress, p)
+		if p.Ready < p.Desired {
+			_, err := clientset.AppsV1().Deployments("rook-ceph").Update(newRolloutTestDeployment(2, p.Updated+1, p.Ready+1))
+			assert.NoError(t, err)
+		}
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []RolloutProgress{
+		{Desired: 2, Updated: 0, Ready: 0},
+		{Desired: 2, Updated: 1, Ready: 1},
+		{Desired: 2, Updated: 2, Ready: 2},
+	}, progress)
+}
+
+func TestWaitForRolloutStuck(t *testing.T) {
+	clientset := fake.NewSimpleClientset()
+	stuck := newRolloutTestDeployment(1, 1, 0)
+	stuck.Status.Conditions = []apps.DeploymentCondition{
+		{Type: apps.DeploymentProgressing, Reason: "ProgressDeadlineExceeded", Message: "pod is pending"},
+	}
+	d, err := clientset.AppsV1().Deployments("rook-ceph").Create(stuck)
+	assert.NoError(t, err)
+
+	var progress RolloutProgress
+	err = WaitForRollout(context.TODO(), clientset, d, func(p RolloutProgress) {
+		progress = p
+	})
+	assert.Error(t, err)
+	assert.Equal(t, "pod is pending", progress.StuckReason)
+}
+
+func TestWaitForRolloutContextDone(t *testing.T) {
+	clientset := fake.NewSimpleClientset()
+	d, err := clientset.AppsV1().Deployments("rook-ceph").Create(newRolloutTestDeployment(1, 0, 0))
+	assert.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.TODO(), 100*time.Millisecond)
+	defer cancel()
+	err = WaitForRollout(ctx, clientset, d, nil)
+	assert.Error(t, err)
+}
+
+func TestRolloutProgress(t *testing.T) {
+	// the stuck condition of the previous rollout is ignored until the update is observed
+	d := newRolloutTestDeployment(1, 0, 0)
+	d.Generation = 2
+	d.Status.ObservedGeneration = 1
+	d.Status.Conditions = []apps.DeploymentCondition{
+		{Type: apps.DeploymentProgressing, Reason: "ProgressDeadlineExceeded", Message: "pod is pending"},
+	}
+	progress, complete := rolloutProgress(d)
+	assert.False(t, complete)
+	assert.Equal(t, "", progress.StuckReason)
+	d.Status.ObservedGeneration = 2
+	progress, _ = rolloutProgress(d)
+	assert.Equal(t, "pod is pending", progress.StuckReason)
+
+	// daemonset not yet observed by the controller
+	ds := &apps.DaemonSet{
+		ObjectMeta: metav1.ObjectMeta{Generation: 2},
+		Status: apps.DaemonSetStatus{
+			ObservedGeneration:     1,
+			DesiredNumberScheduled: 3,
+			UpdatedNumberScheduled: 3,
+			NumberReady:            3,
+			NumberAvailable:        3,
+		},
+	}
+	progress, complete = rolloutProgress(ds)
+	assert.False(t, complete)
+	assert.Equal(t, RolloutProgress{Desired: 3, Updated: 3, Ready: 3}, progress)
+	ds.Status.ObservedGeneration = 2
+	_, complete = rolloutProgress(ds)
+	assert.True(t, complete)
+
+	// statefulset revisions must match unless the update strategy is OnDelete
+	replicas := int32(1)
+	ss := &apps.StatefulSet{
+		Spec: apps.StatefulSetSpec{Replicas: &replicas},
+		Status: apps.StatefulSetStatus{
+			UpdatedReplicas: 1,
+			ReadyReplicas:   1,
+			CurrentRevision: "a",
+			UpdateRevision:  "b",
+		},
+	}
+	_, complete = rolloutProgress(ss)
+	assert.False(t, complete)
+	ss.Spec.UpdateStrategy.Type = apps.OnDeleteStatefulSetStrategyType
+	_, complete = rolloutProgress(ss)
+	assert.True(t, complete)
+}
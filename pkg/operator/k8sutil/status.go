@@ -29,4 +29,6 @@ const (
 	ReconcileFailedStatus = "ReconcileFailed"
 	// Created indicates the object just got created
 	Created = "Created"
+	// PausedStatus indicates the reconcile of the CR is paused
+	PausedStatus = "Paused"
 )
@@ -0,0 +1,108 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package exec
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrDryRun is returned for the commands which are not executed in dry-run mode
+var ErrDryRun = errors.New("command not executed in dry-run mode")
+
+// DryRunCommandExecutor is an exec.Executor that only runs the commands which are allowed in
+// dry-run mode, typically the commands that only read the state of the cluster. The other commands
+// are reported to the Skipped callback and fail with ErrDryRun without being executed.
+type DryRunCommandExecutor struct {
+
+	// Executor is probably a exec.CommandExecutor that will run the allowed commands
+	Executor Executor
+
+	// Allowed returns whether the command can run in dry-run mode
+	Allowed func(command string, arg ...string) bool
+
+	// Skipped is called for every command that is not executed
+	Skipped func(command string, arg ...string)
+}
+
+func (e *DryRunCommandExecutor) allowed(command string, arg ...string) bool {
+	if e.Allowed(command, arg...) {
+		return true
+	}
+	if e.Skipped != nil {
+		e.Skipped(command, arg...)
+	}
+	return false
+}
+
+// ExecuteCommand starts a process and wait for its completion
+func (e *DryRunCommandExecutor) ExecuteCommand(command string, arg ...string) error {
+	if !e.allowed(command, arg...) {
+		return ErrDryRun
+	}
+	return e.Executor.ExecuteCommand(command, arg...)
+}
+
+// ExecuteCommandWithEnv starts a process with an env variable and wait for its completion
+func (e *DryRunCommandExecutor) ExecuteCommandWithEnv(env []string, command string, arg ...string) error {
+	if !e.allowed(command, arg...) {
+		return ErrDryRun
+	}
+	return e.Executor.ExecuteCommandWithEnv(env, command, arg...)
+}
+
+// ExecuteCommandWithOutput starts a process and wait for its completion
+func (e *DryRunCommandExecutor) ExecuteCommandWithOutput(command string, arg ...string) (string, error) {
+	if !e.allowed(command, arg...) {
+		return "", ErrDryRun
+	}
+	return e.Executor.ExecuteCommandWithOutput(command, arg...)
+}
+
+// ExecuteCommandWithCombinedOutput starts a process and returns its stdout and stderr combined.
+func (e *DryRunCommandExecutor) ExecuteCommandWithCombinedOutput(command string, arg ...string) (string, error) {
+	if !e.allowed(command, arg...) {
+		return "", ErrDryRun
+	}
+	return e.Executor.ExecuteCommandWithCombinedOutput(command, arg...)
+}
+
+// ExecuteCommandWithOutputFile starts a process and saves output to file
+func (e *DryRunCommandExecutor) ExecuteCommandWithOutputFile(command, outfileArg string, arg ...string) (string, error) {
+	if !e.allowed(command, arg...) {
+		return "", ErrDryRun
+	}
+	return e.Executor.ExecuteCommandWithOutputFile(command, outfileArg, arg...)
+}
+
+// ExecuteCommandWithOutputFileTimeout is the same as ExecuteCommandWithOutputFile but with a timeout limit.
+func (e *DryRunCommandExecutor) ExecuteCommandWithOutputFileTimeout(
+	timeout time.Duration,
+	command, outfileArg string, arg ...string) (string, error) {
+	if !e.allowed(command, arg...) {
+		return "", ErrDryRun
+	}
+	return e.Executor.ExecuteCommandWithOutputFileTimeout(timeout, command, outfileArg, arg...)
+}
+
+// ExecuteCommandWithTimeout starts a process and wait for its completion with timeout.
+func (e *DryRunCommandExecutor) ExecuteCommandWithTimeout(timeout time.Duration, command string, arg ...string) (string, error) {
+	if !e.allowed(command, arg...) {
+		return "", ErrDryRun
+	}
+	return e.Executor.ExecuteCommandWithTimeout(timeout, command, arg...)
+}
@@ -115,6 +115,8 @@ type LocalDisk struct {
 	KernelName string `json:"kernel-name,omitempty"`
 	// Whether this device should be encrypted
 	Encrypted bool `json:"encrypted,omitempty"`
+	// Health is the SMART health of the device, if the device reports it
+	Health *DeviceHealth `json:"health,omitempty"`
 }
 
 // ListDevices list all devices available on a machine
@@ -286,8 +288,9 @@ func GetDiskUUID(device string, executor exec.Executor) (string, error) {
 }
 
 // CheckIfDeviceAvailable checks if a device is available for consumption. The caller
-// needs to decide based on the return values whether it is available.
-func CheckIfDeviceAvailable(executor exec.Executor, devicePath string, pvcBacked bool) (bool, string, error) {
+// needs to decide based on the return values whether it is available. LVs are only
+// available if allowLV is set, when they are backing a PVC or are listed by path.
+func CheckIfDeviceAvailable(executor exec.Executor, devicePath string, allowLV bool) (bool, string, error) {
 	checker := isDeviceAvailable
 
 	isLV, err := IsLV(devicePath, executor)
@@ -295,8 +298,8 @@ func CheckIfDeviceAvailable(executor exec.Executor, devicePath string, pvcBacked
 		return false, "", fmt.Errorf("failed to determine if the device was LV. %v", err)
 	}
 	if isLV {
-		if !pvcBacked {
-			return false, "LV is not supported for non-PVC backed device", nil
+		if !allowLV {
+			return false, "LV is only supported for PVC backed devices or when listed by path", nil
 		}
 		checker = isLVAvailable
 	}
@@ -0,0 +1,113 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sys
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/rook/rook/pkg/util/exec"
+)
+
+const (
+	ataReallocatedSectorsAttr = 5
+)
+
+// the ATA attributes reporting the remaining life of an SSD as a normalized value, 100 being a
+// new device
+var ataWearoutAttrs = map[int]bool{
+	177: true, // Wear_Leveling_Count
+	202: true, // Percent_Lifetime_Remain
+	231: true, // SSD_Life_Left
+	233: true, // Media_Wearout_Indicator
+}
+
+// DeviceHealth is the health of a device reported by its SMART data or its NVMe health log
+type DeviceHealth struct {
+	// Passed is whether the device passed its SMART overall health self-assessment
+	Passed bool `json:"passed"`
+	// MediaWearout is the percentage of the life of the device that is used, as estimated by the device
+	MediaWearout int `json:"mediaWearout"`
+	// ReallocatedSectors is the number of reallocated sectors of ATA devices or the number of media
+	// errors of NVMe devices
+	ReallocatedSectors int64 `json:"reallocatedSectors"`
+}
+
+type smartctlOutput struct {
+	SmartStatus *struct {
+		Passed bool `json:"passed"`
+	} `json:"smart_status"`
+	NVMeHealth *struct {
+		PercentageUsed int   `json:"percentage_used"`
+		MediaErrors    int64 `json:"media_errors"`
+	} `json:"nvme_smart_health_information_log"`
+	ATAAttributes *struct {
+		Table []struct {
+			ID    int `json:"id"`
+			Value int `json:"value"`
+			Raw   struct {
+				Value int64 `json:"value"`
+			} `json:"raw"`
+		} `json:"table"`
+	} `json:"ata_smart_attributes"`
+	SCSIPercentageUsed *int `json:"scsi_percentage_used_endurance_indicator"`
+}
+
+// GetDeviceHealth returns the health of the device read with smartctl. Nil is returned if the
+// device doesn't report its SMART health, as is the case for most virtual devices.
+func GetDeviceHealth(devicePath string, executor exec.Executor) (*DeviceHealth, error) {
+	// smartctl exits with a non-zero code for many reasons, including a failing device, so its
+	// output is parsed whatever the exit code
+	output, err := executor.ExecuteCommandWithCombinedOutput("smartctl", "--json", "--all", devicePath)
+	health, parseErr := parseDeviceHealth(output)
+	if parseErr != nil {
+		if err != nil {
+			return nil, fmt.Errorf("failed to read the smart data of device %q. %v", devicePath, err)
+		}
+		return nil, fmt.Errorf("failed to parse the smart data of device %q. %v", devicePath, parseErr)
+	}
+	return health, nil
+}
+
+func parseDeviceHealth(output string) (*DeviceHealth, error) {
+	var smart smartctlOutput
+	if err := json.Unmarshal([]byte(output), &smart); err != nil {
+		return nil, err
+	}
+	if smart.SmartStatus == nil {
+		return nil, nil
+	}
+
+	health := &DeviceHealth{Passed: smart.SmartStatus.Passed}
+	if smart.NVMeHealth != nil {
+		health.MediaWearout = smart.NVMeHealth.PercentageUsed
+		health.ReallocatedSectors = smart.NVMeHealth.MediaErrors
+	}
+	if smart.SCSIPercentageUsed != nil {
+		health.MediaWearout = *smart.SCSIPercentageUsed
+	}
+	if smart.ATAAttributes != nil {
+		for _, attr := range smart.ATAAttributes.Table {
+			if attr.ID == ataReallocatedSectorsAttr {
+				health.ReallocatedSectors = attr.Raw.Value
+			} else if ataWearoutAttrs[attr.ID] {
+				health.MediaWearout = 100 - attr.Value
+			}
+		}
+	}
+	return health, nil
+}
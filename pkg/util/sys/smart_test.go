@@ -0,0 +1,72 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sys
+
+import (
+	"errors"
+	"testing"
+
+	exectest "github.com/rook/rook/pkg/util/exec/test"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	smartctlNVMeOutput = `{"device":{"name":"/dev/nvme0n1","type":"nvme"},"smart_status":{"passed":true},
+"nvme_smart_health_information_log":{"critical_warning":0,"percentage_used":12,"media_errors":3}}`
+	smartctlATAOutput = `{"device":{"name":"/dev/sda","type":"sat"},"smart_status":{"passed":false},
+"ata_smart_attributes":{"revision":1,"table":[
+{"id":5,"name":"Reallocated_Sector_Ct","value":90,"raw":{"value":120,"string":"120"}},
+{"id":9,"name":"Power_On_Hours","value":95,"raw":{"value":20000,"string":"20000"}},
+{"id":177,"name":"Wear_Leveling_Count","value":70,"raw":{"value":900,"string":"900"}}]}}`
+	smartctlVirtualOutput = `{"device":{"name":"/dev/vda","type":"scsi"},"smartctl":{"exit_status":4}}`
+)
+
+func TestGetDeviceHealth(t *testing.T) {
+	output := ""
+	var outputErr error
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithCombinedOutput: func(command string, arg ...string) (string, error) {
+			assert.Equal(t, "smartctl", command)
+			return output, outputErr
+		},
+	}
+
+	output = smartctlNVMeOutput
+	health, err := GetDeviceHealth("/dev/nvme0n1", executor)
+	assert.NoError(t, err)
+	assert.Equal(t, &DeviceHealth{Passed: true, MediaWearout: 12, ReallocatedSectors: 3}, health)
+
+	// the output is parsed even if smartctl reports the failing device with its exit code
+	output = smartctlATAOutput
+	outputErr = errors.New("exit status 8")
+	health, err = GetDeviceHealth("/dev/sda", executor)
+	assert.NoError(t, err)
+	assert.Equal(t, &DeviceHealth{Passed: false, MediaWearout: 30, ReallocatedSectors: 120}, health)
+
+	// no smart data
+	output = smartctlVirtualOutput
+	outputErr = errors.New("exit status 4")
+	health, err = GetDeviceHealth("/dev/vda", executor)
+	assert.NoError(t, err)
+	assert.Nil(t, health)
+
+	// smartctl is not installed
+	output = "exec: \"smartctl\": executable file not found in $PATH"
+	outputErr = errors.New("not found")
+	_, err = GetDeviceHealth("/dev/vda", executor)
+	assert.Error(t, err)
+}
@@ -592,7 +592,9 @@ func (h *CephInstaller) UninstallRookFromMultipleNS(systemNamespace string, name
 		"volumes.rook.io",
 		"objectbuckets.objectbucket.io",
 		"objectbucketclaims.objectbucket.io",
-		"cephrbdmirrors.ceph.rook.io")
+		"cephrbdmirrors.ceph.rook.io",
+		"cephosdremovals.ceph.rook.io",
+		"cephdashboardusers.ceph.rook.io")
 	checkError(h.T(), err, "cannot delete CRDs")
 
 	if h.useHelm {
@@ -127,6 +127,20 @@ spec:
                   maximum: 65535
                 ssl:
                   type: boolean
+                sso:
+                  properties:
+                    enabled:
+                      type: boolean
+                    baseURL:
+                      type: string
+                    idpMetadataURL:
+                      type: string
+                    idpUsernameAttribute:
+                      type: string
+                    idpEntityID:
+                      type: string
+                    secretName:
+                      type: string
             dataDirHostPath:
               pattern: ^/(\S+)
               type: string
@@ -160,6 +174,7 @@ spec:
                         type: string
                       enabled:
                         type: boolean
+                      settings: {}
             network:
               properties:
                 hostNetwork:
@@ -267,6 +282,14 @@ spec:
                       format: int32
             placement: {}
             resources: {}
+            security:
+              properties:
+                keyRotation:
+                  properties:
+                    enabled:
+                      type: boolean
+                    schedule:
+                      type: string
   additionalPrinterColumns:
     - name: DataDirHostPath
       type: string
@@ -784,6 +807,83 @@ spec:
               type: integer
               minimum: 1
               maximum: 100
+  subresources:
+    status: {}
+---
+apiVersion: apiextensions.k8s.io/v1beta1
+kind: CustomResourceDefinition
+metadata:
+  name: cephosdremovals.ceph.rook.io
+spec:
+  group: ceph.rook.io
+  names:
+    kind: CephOSDRemoval
+    listKind: CephOSDRemovalList
+    plural: cephosdremovals
+    singular: cephosdremoval
+  scope: Namespaced
+  version: v1
+  validation:
+    openAPIV3Schema:
+      properties:
+        spec:
+          properties:
+            osdIDs:
+              type: array
+              items:
+                type: integer
+                minimum: 0
+            nodeName:
+              type: string
+  additionalPrinterColumns:
+    - name: Phase
+      type: string
+      description: Phase of the removal
+      JSONPath: .status.phase
+    - name: Message
+      type: string
+      description: Progress of the removal
+      JSONPath: .status.message
+  subresources:
+    status: {}
+---
+apiVersion: apiextensions.k8s.io/v1beta1
+kind: CustomResourceDefinition
+metadata:
+  name: cephdashboardusers.ceph.rook.io
+spec:
+  group: ceph.rook.io
+  names:
+    kind: CephDashboardUser
+    listKind: CephDashboardUserList
+    plural: cephdashboardusers
+    singular: cephdashboarduser
+  scope: Namespaced
+  version: v1
+  validation:
+    openAPIV3Schema:
+      properties:
+        spec:
+          properties:
+            username:
+              type: string
+            passwordSecret:
+              type: string
+            roles:
+              type: array
+              items:
+                type: string
+            name:
+              type: string
+            email:
+              type: string
+          required:
+          - passwordSecret
+  additionalPrinterColumns:
+    - name: Phase
+      type: string
+      description: Phase of the user
+      JSONPath: .status.phase
   subresources:
     status: {}`
 }
@@ -913,6 +1013,7 @@ rules:
   - batch
   resources:
   - jobs
+  - cronjobs
   verbs:
   - get
   - list
@@ -1645,6 +1746,10 @@ rules:
 - apiGroups: ["ceph.rook.io"]
   resources: ["cephclusters", "cephclusters/finalizers"]
   verbs: [ "get", "list", "create", "update", "delete" ]
+# the osd encryption keys are updated when they are rotated
+- apiGroups: [""]
+  resources: ["secrets"]
+  verbs: [ "get", "update" ]
 ---
 # Aspects of ceph-mgr that operate within the cluster's namespace
 kind: Role